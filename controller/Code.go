@@ -1,5 +1,9 @@
 package controller
 
+import (
+	"bluebell/pkg/i18n"
+	"net/http"
+)
 
 type ResCode int64
 
@@ -13,24 +17,91 @@ const (
 
 	CodeNeedLogin
 	CodeInvalidToken
+	CodeNoPermission
+	CodeUserBanned
+	CodePostLocked
+	CodeCommunityExist
+	CodeCommunityArchived
+
+	CodeTooManyRequests
+	CodeLoginLocked
+
+	CodeNotFound
+	CodeVoteTimeExpire
+	CodeVoteRepeated
 )
 
-var codeMsgMap = map[ResCode]string{
+// codeKeyMap 错误码对应的提示信息在消息文件(conf/i18n/*.yaml)中的key
+var codeKeyMap = map[ResCode]string{
 	CodeSuccess:         "success",
-	CodeInvalidParam:    "请求参数错误",
-	CodeUserExist:       "用户名已存在",
-	CodeUserNotExist:    "用户名不存在",
-	CodeInvalidPassword: "用户名或密码错误",
-	CodeServerBusy:      "服务繁忙",
-
-	CodeNeedLogin:    "需要登录",
-	CodeInvalidToken: "无效的token",
+	CodeInvalidParam:    "invalid_param",
+	CodeUserExist:       "user_exist",
+	CodeUserNotExist:    "user_not_exist",
+	CodeInvalidPassword: "invalid_password",
+	CodeServerBusy:      "server_busy",
+
+	CodeNeedLogin:    "need_login",
+	CodeInvalidToken: "invalid_token",
+	CodeNoPermission: "no_permission",
+	CodeUserBanned:   "user_banned",
+	CodePostLocked:   "post_locked",
+
+	CodeCommunityExist:    "community_exist",
+	CodeCommunityArchived: "community_archived",
+
+	CodeTooManyRequests: "too_many_requests",
+	CodeLoginLocked:     "login_locked",
+
+	CodeNotFound:       "not_found",
+	CodeVoteTimeExpire: "vote_time_expire",
+	CodeVoteRepeated:   "vote_repeated",
+}
+
+// codeStatusMap /api/v2返回错误时使用的http状态码，未列出的错误码返回500
+var codeStatusMap = map[ResCode]int{
+	CodeSuccess:         http.StatusOK,
+	CodeInvalidParam:    http.StatusBadRequest,
+	CodeUserExist:       http.StatusConflict,
+	CodeUserNotExist:    http.StatusNotFound,
+	CodeInvalidPassword: http.StatusUnauthorized,
+	CodeServerBusy:      http.StatusInternalServerError,
+
+	CodeNeedLogin:    http.StatusUnauthorized,
+	CodeInvalidToken: http.StatusUnauthorized,
+	CodeNoPermission: http.StatusForbidden,
+	CodeUserBanned:   http.StatusForbidden,
+	CodePostLocked:   http.StatusForbidden,
+
+	CodeCommunityExist:    http.StatusConflict,
+	CodeCommunityArchived: http.StatusForbidden,
+
+	CodeTooManyRequests: http.StatusTooManyRequests,
+	CodeLoginLocked:     http.StatusTooManyRequests,
+
+	CodeNotFound:       http.StatusNotFound,
+	CodeVoteTimeExpire: http.StatusForbidden,
+	CodeVoteRepeated:   http.StatusConflict,
 }
 
+// Msg 默认语言的提示信息
 func (c ResCode) Msg() string {
-	msg,ok := codeMsgMap[c]
+	return c.MsgIn(i18n.Default())
+}
+
+// MsgIn 指定语言的提示信息
+func (c ResCode) MsgIn(lang string) string {
+	key, ok := codeKeyMap[c]
 	if !ok {
-		msg = codeMsgMap[CodeServerBusy]
+		key = codeKeyMap[CodeServerBusy]
 	}
-	return msg
-}
\ No newline at end of file
+	return i18n.T(lang, key)
+}
+
+// HTTPStatus 错误码对应的http状态码
+func (c ResCode) HTTPStatus() int {
+	status, ok := codeStatusMap[c]
+	if !ok {
+		status = http.StatusInternalServerError
+	}
+	return status
+}
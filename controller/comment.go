@@ -0,0 +1,94 @@
+package controller
+
+import (
+	"bluebell/logger"
+	"bluebell/logic"
+	"bluebell/models"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+// CreateCommentHandler 发表评论
+func CreateCommentHandler(c *gin.Context) {
+	postID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		ResponseError(c, CodeInvalidParam)
+		return
+	}
+	p := new(models.ParamComment)
+	if err := c.ShouldBindJSON(p); err != nil {
+		logger.FromContext(c.Request.Context()).Error("create comment with invalid param", zap.Error(err))
+		errs, ok := err.(validator.ValidationErrors)
+		if !ok {
+			ResponseError(c, CodeInvalidParam)
+			return
+		}
+		ResponseErrorWithMsg(c, CodeInvalidParam, removeTopStruct(errs.Translate(getTranslator(c))))
+		return
+	}
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		ResponseError(c, CodeNeedLogin)
+		return
+	}
+	comment, err := logic.CreateComment(c.Request.Context(), userID, postID, p)
+	if err != nil {
+		responseLogicError(c, "logic.CreateComment failed", err)
+		return
+	}
+	ResponseSuccess(c, comment)
+}
+
+// GetCommentListHandler 获取帖子的评论树
+// GET请求参数(query string)：/api/v1/post/:id/comments?page=1&size=10&order=time&depth=3
+func GetCommentListHandler(c *gin.Context) {
+	postID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		ResponseError(c, CodeInvalidParam)
+		return
+	}
+	p := &models.ParamCommentList{
+		Depth: 3,
+		Page:  1,
+		Size:  10,
+		Order: models.OrderTime,
+	}
+	if err := c.ShouldBindQuery(p); err != nil {
+		logger.FromContext(c.Request.Context()).Error("GetCommentListHandler with invalid params", zap.Error(err))
+		ResponseError(c, CodeInvalidParam)
+		return
+	}
+	data, err := logic.GetCommentTree(c.Request.Context(), postID, p)
+	if err != nil {
+		responseLogicError(c, "logic.GetCommentTree() failed", err)
+		return
+	}
+	ResponseSuccess(c, data)
+}
+
+// CommentVoteController 为评论投票
+func CommentVoteController(c *gin.Context) {
+	p := new(models.ParamCommentVoteData)
+	if err := c.ShouldBindJSON(p); err != nil {
+		errs, ok := err.(validator.ValidationErrors)
+		if !ok {
+			ResponseError(c, CodeInvalidParam)
+			return
+		}
+		ResponseErrorWithMsg(c, CodeInvalidParam, removeTopStruct(errs.Translate(getTranslator(c))))
+		return
+	}
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		ResponseError(c, CodeNeedLogin)
+		return
+	}
+	if err := logic.VoteForComment(c.Request.Context(), userID, p); err != nil {
+		responseLogicError(c, "logic.VoteForComment() failed", err)
+		return
+	}
+	ResponseSuccess(c, nil)
+}
@@ -0,0 +1,27 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 分页或排序参数不合法时在参数校验阶段返回，不会进入logic计算分页区间
+func TestGetCommentListInvalidPage(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/post/:id/comments", GetCommentListHandler)
+	for _, query := range []string{"page=0", "page=-1", "size=0", "size=-5", "size=101", "order=foo"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/post/1/comments?"+query, nil))
+		var resp ResponseData
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("%s: unmarshal response failed, err:%v", query, err)
+		}
+		if resp.Code != CodeInvalidParam {
+			t.Errorf("%s: got code %d, want %d", query, resp.Code, CodeInvalidParam)
+		}
+	}
+}
@@ -1,10 +1,13 @@
 package controller
 
 import (
+	"bluebell/logger"
 	"bluebell/logic"
+	"bluebell/models"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
 	"go.uber.org/zap"
 )
 
@@ -12,10 +15,9 @@ import (
 
 func CommunityHandler(c *gin.Context) {
 	// 查询到所有的社区（community_id, community_name) 以列表的形式返回
-	data, err := logic.GetCommunityList()
+	data, err := logic.GetCommunityList(c.Request.Context())
 	if err != nil {
-		zap.L().Error("logic.GetCommunityList() failed", zap.Error(err))
-		ResponseError(c, CodeServerBusy) // 不轻易把服务端报错暴露给外面
+		responseLogicError(c, "logic.GetCommunityList() failed", err) // 不轻易把服务端报错暴露给外面
 		return
 	}
 	ResponseSuccess(c, data)
@@ -31,11 +33,85 @@ func CommunityDetailHandler(c *gin.Context) {
 		return
 	}
 	// 根据id获取社区详情
-	data, err := logic.GetCommunityDetail(id)
+	data, err := logic.GetCommunityDetail(c.Request.Context(), id)
 	if err != nil {
-		zap.L().Error("logic.GetCommunityList() failed", zap.Error(err))
-		ResponseError(c, CodeServerBusy) // 不轻易把服务端报错暴露给外面
+		responseLogicError(c, "logic.GetCommunityDetail() failed", err) // 不轻易把服务端报错暴露给外面
 		return
 	}
 	ResponseSuccess(c, data)
 }
+
+// CreateCommunityHandler 创建社区
+func CreateCommunityHandler(c *gin.Context) {
+	p := new(models.ParamCommunity)
+	if err := c.ShouldBindJSON(p); err != nil {
+		logger.FromContext(c.Request.Context()).Error("create community with invalid param", zap.Error(err))
+		errs, ok := err.(validator.ValidationErrors)
+		if !ok {
+			ResponseError(c, CodeInvalidParam)
+			return
+		}
+		ResponseErrorWithMsg(c, CodeInvalidParam, removeTopStruct(errs.Translate(getTranslator(c))))
+		return
+	}
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		ResponseError(c, CodeNeedLogin)
+		return
+	}
+	data, err := logic.CreateCommunity(c.Request.Context(), userID, p)
+	if err != nil {
+		responseLogicError(c, "logic.CreateCommunity failed", err)
+		return
+	}
+	ResponseSuccess(c, data)
+}
+
+// UpdateCommunityHandler 修改社区信息
+func UpdateCommunityHandler(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		ResponseError(c, CodeInvalidParam)
+		return
+	}
+	p := new(models.ParamCommunity)
+	if err := c.ShouldBindJSON(p); err != nil {
+		logger.FromContext(c.Request.Context()).Error("update community with invalid param", zap.Error(err))
+		errs, ok := err.(validator.ValidationErrors)
+		if !ok {
+			ResponseError(c, CodeInvalidParam)
+			return
+		}
+		ResponseErrorWithMsg(c, CodeInvalidParam, removeTopStruct(errs.Translate(getTranslator(c))))
+		return
+	}
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		ResponseError(c, CodeNeedLogin)
+		return
+	}
+	if err := logic.UpdateCommunity(c.Request.Context(), userID, GetCurrentUserRole(c), id, p); err != nil {
+		responseLogicError(c, "logic.UpdateCommunity failed", err)
+		return
+	}
+	ResponseSuccess(c, nil)
+}
+
+// ArchiveCommunityHandler 归档社区
+func ArchiveCommunityHandler(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		ResponseError(c, CodeInvalidParam)
+		return
+	}
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		ResponseError(c, CodeNeedLogin)
+		return
+	}
+	if err := logic.ArchiveCommunity(c.Request.Context(), userID, GetCurrentUserRole(c), id); err != nil {
+		responseLogicError(c, "logic.ArchiveCommunity failed", err)
+		return
+	}
+	ResponseSuccess(c, nil)
+}
@@ -0,0 +1,12 @@
+package controller
+
+import (
+	"bluebell/dao/cache"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CacheStatsHandler 查看缓存的命中情况
+func CacheStatsHandler(c *gin.Context) {
+	ResponseSuccess(c, cache.GetStats())
+}
@@ -0,0 +1,111 @@
+package controller
+
+import (
+	"bluebell/dao/mysql"
+	"bluebell/dao/redis"
+	"bluebell/logger"
+	"bluebell/logic"
+	"database/sql"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// AppError 返回给客户端的业务错误，Code决定返回的错误码，http状态码由Code对应
+type AppError struct {
+	Code ResCode
+	Err  error // 原始错误，只记录日志，不返回给客户端
+}
+
+// NewAppError 用业务错误码包装原始错误
+func NewAppError(code ResCode, err error) *AppError {
+	return &AppError{Code: code, Err: err}
+}
+
+func (e *AppError) Error() string {
+	if e.Err == nil {
+		return e.Code.Msg()
+	}
+	return e.Err.Error()
+}
+
+func (e *AppError) Unwrap() error {
+	return e.Err
+}
+
+// Status 错误对应的http状态码
+func (e *AppError) Status() int {
+	return e.Code.HTTPStatus()
+}
+
+// errCodes dao层和logic层的错误对应的业务错误码，未列出的错误都是CodeServerBusy
+var errCodes = []struct {
+	err  error
+	code ResCode
+}{
+	{sql.ErrNoRows, CodeNotFound},
+	{mysql.ErrorInvalidID, CodeNotFound},
+	{mysql.ErrorUserExist, CodeUserExist},
+	{mysql.ErrorUserNotExist, CodeUserNotExist},
+	{mysql.ErrorInvalidPassword, CodeInvalidPassword},
+	{mysql.ErrorCommunityExist, CodeCommunityExist},
+	{redis.ErrVoteTimeExpire, CodeVoteTimeExpire},
+	{redis.ErrVoteRepeated, CodeVoteRepeated},
+	{redis.ErrInvalidCursor, CodeInvalidParam},
+	{logic.ErrorInvalidToken, CodeInvalidToken},
+	{logic.ErrorNoPermission, CodeNoPermission},
+	{logic.ErrorUserBanned, CodeUserBanned},
+	{logic.ErrorPostLocked, CodePostLocked},
+	{logic.ErrorCommunityArchived, CodeCommunityArchived},
+}
+
+// v1ErrCodes /api/v1中仍然使用拆分出新错误码之前的错误码，兼容旧的客户端，按顺序匹配
+var v1ErrCodes = []struct {
+	err  error
+	code ResCode
+}{
+	{mysql.ErrorInvalidID, CodeInvalidParam},
+	{sql.ErrNoRows, CodeServerBusy},
+	{redis.ErrVoteTimeExpire, CodeServerBusy},
+	{redis.ErrVoteRepeated, CodeServerBusy},
+}
+
+// ToAppError 把logic层返回的错误转换成AppError
+func ToAppError(err error) *AppError {
+	var appErr *AppError
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	var lockedErr *logic.LoginLockedError
+	if errors.As(err, &lockedErr) {
+		return NewAppError(CodeLoginLocked, err)
+	}
+	for _, e := range errCodes {
+		if errors.Is(err, e.err) {
+			return NewAppError(e.code, err)
+		}
+	}
+	return NewAppError(CodeServerBusy, err)
+}
+
+// logicErrorCode logic层返回的错误在当前接口版本中对应的错误码
+func logicErrorCode(c *gin.Context, err error) ResCode {
+	if c.GetInt(CtxAPIVersionKey) < 2 {
+		for _, e := range v1ErrCodes {
+			if errors.Is(err, e.err) {
+				return e.code
+			}
+		}
+	}
+	return ToAppError(err).Code
+}
+
+// responseLogicError 根据logic层返回的错误返回对应的错误码，未知错误记录日志后返回CodeServerBusy
+func responseLogicError(c *gin.Context, msg string, err error) {
+	code := logicErrorCode(c, err)
+	if code == CodeServerBusy {
+		logger.FromContext(c.Request.Context()).Error(msg, zap.Error(err))
+	}
+	ResponseError(c, code)
+}
@@ -0,0 +1,36 @@
+package controller
+
+import (
+	"bluebell/dao/mysql"
+	"bluebell/dao/redis"
+	"database/sql"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// /api/v1保持原来的错误码，/api/v2使用新的错误码
+func TestLogicErrorCode(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		err    error
+		v1, v2 ResCode
+	}{
+		{mysql.ErrorInvalidID, CodeInvalidParam, CodeNotFound},
+		{fmt.Errorf("get post: %w", sql.ErrNoRows), CodeServerBusy, CodeNotFound},
+		{redis.ErrVoteTimeExpire, CodeServerBusy, CodeVoteTimeExpire},
+		{redis.ErrVoteRepeated, CodeServerBusy, CodeVoteRepeated},
+		{mysql.ErrorCommunityExist, CodeCommunityExist, CodeCommunityExist},
+	}
+	for _, tt := range tests {
+		for version, want := range map[int]ResCode{1: tt.v1, 2: tt.v2} {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Set(CtxAPIVersionKey, version)
+			if got := logicErrorCode(c, tt.err); got != want {
+				t.Errorf("v%d %v: got code %d, want %d", version, tt.err, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package controller
+
+import (
+	"bluebell/logic"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HealthzHandler 存活检查，进程能处理请求即返回200
+func HealthzHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": logic.HealthStatusUp})
+}
+
+// ReadyzHandler 就绪检查，依赖不可用或正在退出时返回503
+func ReadyzHandler(c *gin.Context) {
+	report := logic.CheckReadiness(c.Request.Context())
+	status := http.StatusOK
+	if report.Status != logic.HealthStatusUp {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, report)
+}
@@ -0,0 +1,127 @@
+package controller
+
+import (
+	"bluebell/logger"
+	"bluebell/logic"
+	"bluebell/models"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// 版主及管理员的管理操作
+
+// RemovePostHandler 删除帖子
+func RemovePostHandler(c *gin.Context) {
+	pid, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		ResponseError(c, CodeInvalidParam)
+		return
+	}
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		ResponseError(c, CodeNeedLogin)
+		return
+	}
+	if err := logic.RemovePost(c.Request.Context(), userID, GetCurrentUserRole(c), pid); err != nil {
+		responseLogicError(c, "logic.RemovePost failed", err)
+		return
+	}
+	ResponseSuccess(c, nil)
+}
+
+// LockPostHandler 锁定帖子
+func LockPostHandler(c *gin.Context) {
+	lockPost(c, true)
+}
+
+// UnlockPostHandler 解锁帖子
+func UnlockPostHandler(c *gin.Context) {
+	lockPost(c, false)
+}
+
+func lockPost(c *gin.Context, locked bool) {
+	pid, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		ResponseError(c, CodeInvalidParam)
+		return
+	}
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		ResponseError(c, CodeNeedLogin)
+		return
+	}
+	if err := logic.LockPost(c.Request.Context(), userID, GetCurrentUserRole(c), pid, locked); err != nil {
+		responseLogicError(c, "logic.LockPost failed", err)
+		return
+	}
+	ResponseSuccess(c, nil)
+}
+
+// BanUserHandler 封禁用户
+func BanUserHandler(c *gin.Context) {
+	banUser(c, true)
+}
+
+// UnbanUserHandler 解封用户
+func UnbanUserHandler(c *gin.Context) {
+	banUser(c, false)
+}
+
+func banUser(c *gin.Context, banned bool) {
+	uid, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		ResponseError(c, CodeInvalidParam)
+		return
+	}
+	operatorID, err := getCurrentUserID(c)
+	if err != nil {
+		ResponseError(c, CodeNeedLogin)
+		return
+	}
+	if err := logic.BanUser(c.Request.Context(), operatorID, uid, banned); err != nil {
+		responseLogicError(c, "logic.BanUser failed", err)
+		return
+	}
+	ResponseSuccess(c, nil)
+}
+
+// AddModeratorHandler 指派社区版主
+func AddModeratorHandler(c *gin.Context) {
+	cid, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		ResponseError(c, CodeInvalidParam)
+		return
+	}
+	p := new(models.ParamModerator)
+	if err := c.ShouldBindJSON(p); err != nil {
+		logger.FromContext(c.Request.Context()).Error("add moderator with invalid param", zap.Error(err))
+		ResponseError(c, CodeInvalidParam)
+		return
+	}
+	if err := logic.AddModerator(c.Request.Context(), cid, p.UserID); err != nil {
+		responseLogicError(c, "logic.AddModerator failed", err)
+		return
+	}
+	ResponseSuccess(c, nil)
+}
+
+// RemoveModeratorHandler 撤销社区版主
+func RemoveModeratorHandler(c *gin.Context) {
+	cid, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		ResponseError(c, CodeInvalidParam)
+		return
+	}
+	uid, err := strconv.ParseInt(c.Param("uid"), 10, 64)
+	if err != nil {
+		ResponseError(c, CodeInvalidParam)
+		return
+	}
+	if err := logic.RemoveModerator(c.Request.Context(), cid, uid); err != nil {
+		responseLogicError(c, "logic.RemoveModerator failed", err)
+		return
+	}
+	ResponseSuccess(c, nil)
+}
@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"bluebell/logger"
 	"bluebell/logic"
 	"bluebell/models"
 	"strconv"
@@ -15,8 +16,8 @@ func CreatePostHandler(c *gin.Context) {
 	// c.ShouldBindJSON()  // validator --> binding tag
 	p := new(models.Post)
 	if err := c.ShouldBindJSON(p); err != nil {
-		zap.L().Debug("c.ShouldBindJSON(p) error", zap.Any("err", err))
-		zap.L().Error("create post with invalid param")
+		logger.FromContext(c.Request.Context()).Debug("c.ShouldBindJSON(p) error", zap.Any("err", err))
+		logger.FromContext(c.Request.Context()).Error("create post with invalid param")
 		ResponseError(c, CodeInvalidParam)
 		return
 	}
@@ -28,9 +29,8 @@ func CreatePostHandler(c *gin.Context) {
 	}
 	p.AuthorID = userID
 	// 2. 创建帖子
-	if err := logic.CreatePost(p); err != nil {
-		zap.L().Error("logic.CreatePost(p) failed", zap.Error(err))
-		ResponseError(c, CodeServerBusy)
+	if err := logic.CreatePost(c.Request.Context(), p); err != nil {
+		responseLogicError(c, "logic.CreatePost(p) failed", err)
 		return
 	}
 	// 3. 返回响应
@@ -43,15 +43,14 @@ func GetPostDetailHandler(c *gin.Context) {
 	pidStr := c.Param("id")
 	pid, err := strconv.ParseInt(pidStr, 10, 64)
 	if err != nil {
-		zap.L().Error("get post detail with invalid param", zap.Error(err))
+		logger.FromContext(c.Request.Context()).Error("get post detail with invalid param", zap.Error(err))
 		ResponseError(c, CodeInvalidParam)
 		return
 	}
 	// 2. 根据id取出帖子数据 (查数据库)
-	data, err := logic.GetPostById(pid)
+	data, err := logic.GetPostById(c.Request.Context(), pid)
 	if err != nil {
-		zap.L().Error("logic.GetPostById(pid) failed", zap.Error(err))
-		ResponseError(c, CodeServerBusy)
+		responseLogicError(c, "logic.GetPostById(pid) failed", err)
 		return
 	}
 	// 3. 返回响应
@@ -63,10 +62,9 @@ func GetPostListHandler(c *gin.Context) {
 	// 获取分页参数
 	page, size := getPageInfo(c)
 	// 获取数据
-	data, err := logic.GetPostList(page, size)
+	data, err := logic.GetPostList(c.Request.Context(), page, size)
 	if err != nil {
-		zap.L().Error("logic.GetPostList() failed", zap.Error(err))
-		ResponseError(c, CodeServerBusy)
+		responseLogicError(c, "logic.GetPostList() failed", err)
 		return
 	}
 	ResponseSuccess(c, data)
@@ -95,17 +93,89 @@ func GetPostListHandler2(c *gin.Context) {
 	//c.ShouldBind()  根据请求的数据类型选择相应的方法去获取数据
 	//c.ShouldBindJSON() 如果请求中携带的是json格式的数据，才能用这个方法获取到数据
 	if err := c.ShouldBindQuery(p); err != nil {
-		zap.L().Error("GetPostListHandler2 with invalid params", zap.Error(err))
+		logger.FromContext(c.Request.Context()).Error("GetPostListHandler2 with invalid params", zap.Error(err))
 		ResponseError(c, CodeInvalidParam)
 		return
 	}
-	data, err := logic.GetPostListNew(p) // 更新：合二为一
+	// 带有cursor参数时使用游标分页：/api/v1/posts2?cursor=&size=10 第一页cursor为空
+	_, p.UseCursor = c.GetQuery("cursor")
+	data, err := logic.GetPostListNew(c.Request.Context(), p) // 更新：合二为一
 	// 获取数据
 	if err != nil {
-		zap.L().Error("logic.GetPostList() failed", zap.Error(err))
-		ResponseError(c, CodeServerBusy)
+		responseLogicError(c, "logic.GetPostList() failed", err)
 		return
 	}
-	ResponseSuccess(c, data)
+	responsePostList(c, p, data)
 	// 返回响应
-}
\ No newline at end of file
+}
+
+// responsePostList 游标分页时返回列表和下一页的游标，页码分页时保持原来只返回列表的格式
+func responsePostList(c *gin.Context, p *models.ParamPostList, data *models.ApiPostList) {
+	if p.UseCursor {
+		if data.List == nil {
+			data.List = []*models.ApiPostDetail{}
+		}
+		ResponseSuccess(c, data)
+		return
+	}
+	ResponseSuccess(c, data.List)
+}
+
+// UpdatePostHandler 编辑帖子
+func UpdatePostHandler(c *gin.Context) {
+	pid, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		ResponseError(c, CodeInvalidParam)
+		return
+	}
+	p := new(models.ParamUpdatePost)
+	if err := c.ShouldBindJSON(p); err != nil {
+		logger.FromContext(c.Request.Context()).Error("update post with invalid param", zap.Error(err))
+		ResponseError(c, CodeInvalidParam)
+		return
+	}
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		ResponseError(c, CodeNeedLogin)
+		return
+	}
+	if err := logic.UpdatePost(c.Request.Context(), userID, pid, p); err != nil {
+		responseLogicError(c, "logic.UpdatePost failed", err)
+		return
+	}
+	ResponseSuccess(c, nil)
+}
+
+// DeletePostHandler 删除帖子
+func DeletePostHandler(c *gin.Context) {
+	pid, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		ResponseError(c, CodeInvalidParam)
+		return
+	}
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		ResponseError(c, CodeNeedLogin)
+		return
+	}
+	if err := logic.DeletePost(c.Request.Context(), userID, pid); err != nil {
+		responseLogicError(c, "logic.DeletePost failed", err)
+		return
+	}
+	ResponseSuccess(c, nil)
+}
+
+// GetPostRevisionsHandler 获取帖子的编辑历史
+func GetPostRevisionsHandler(c *gin.Context) {
+	pid, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		ResponseError(c, CodeInvalidParam)
+		return
+	}
+	data, err := logic.GetPostRevisions(c.Request.Context(), pid)
+	if err != nil {
+		responseLogicError(c, "logic.GetPostRevisions failed", err)
+		return
+	}
+	ResponseSuccess(c, data)
+}
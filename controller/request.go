@@ -1,13 +1,21 @@
 package controller
 
 import (
+	"bluebell/models"
+	"bluebell/pkg/i18n"
 	"errors"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
 
-const CtxUserIDKey = "userID"
+const (
+	CtxUserIDKey     = "userID"
+	CtxUserRoleKey   = "userRole"
+	CtxRequestIDKey  = "requestID"
+	CtxAPIVersionKey = "apiVersion"
+	CtxLangKey       = "lang"
+)
 
 var ErrorUserNotLogin = errors.New("用户未登录")
 
@@ -26,6 +34,13 @@ func getCurrentUserID(c *gin.Context) (userID int64, err error) {
 	return
 }
 
+// GetCurrentUserRole 获取当前登录用户的角色
+func GetCurrentUserRole(c *gin.Context) models.Role {
+	role, _ := c.Get(CtxUserRoleKey)
+	r, _ := role.(models.Role)
+	return r
+}
+
 func getPageInfo(c *gin.Context) (int64, int64) {
 	pageStr := c.Query("page")
 	sizeStr := c.Query("size")
@@ -46,3 +61,11 @@ func getPageInfo(c *gin.Context) (int64, int64) {
 	}
 	return page, size
 }
+
+// getLang 获取当前请求使用的语言
+func getLang(c *gin.Context) string {
+	if lang := c.GetString(CtxLangKey); lang != "" {
+		return lang
+	}
+	return i18n.Default()
+}
@@ -1,9 +1,14 @@
 package controller
 
 import (
+	"bluebell/logger"
+	"bytes"
+	"encoding/json"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 )
 
 /*
@@ -11,36 +16,102 @@ import (
 	"code": 10000, // 程序中的错误码
 	"msg": xx,     // 提示信息
 	"data": {},    // 数据
+	"request_id": "xx", // 请求id，只在返回错误时携带，方便用户反馈问题
 }
 
 */
 
 type ResponseData struct {
-	Code ResCode     `json:"code"`
-	Msg  interface{} `json:"msg"`
-	Data interface{} `json:"data,omitempty"`
+	Code      ResCode     `json:"code"`
+	Msg       interface{} `json:"msg"`
+	Data      interface{} `json:"data,omitempty"`
+	RequestID string      `json:"request_id,omitempty"`
 }
 
+// ResponseError 返回错误，/api/v1始终使用200，/api/v2使用错误码对应的http状态码
 func ResponseError(c *gin.Context, code ResCode) {
-	c.JSON(http.StatusOK, &ResponseData{
-		Code: code,
-		Msg:  code.Msg(),
-		Data: nil,
+	c.JSON(errorStatus(c, code), &ResponseData{
+		Code:      code,
+		Msg:       code.MsgIn(getLang(c)),
+		Data:      nil,
+		RequestID: c.GetString(CtxRequestIDKey),
+	})
+}
+
+// ResponseErrorWithStatus 返回错误的同时使用指定的http状态码
+func ResponseErrorWithStatus(c *gin.Context, status int, code ResCode) {
+	c.JSON(status, &ResponseData{
+		Code:      code,
+		Msg:       code.MsgIn(getLang(c)),
+		Data:      nil,
+		RequestID: c.GetString(CtxRequestIDKey),
 	})
 }
 
 func ResponseErrorWithMsg(c *gin.Context, code ResCode, msg interface{}) {
-	c.JSON(http.StatusOK, &ResponseData{
-		Code: code,
-		Msg:  msg,
-		Data: nil,
+	c.JSON(errorStatus(c, code), &ResponseData{
+		Code:      code,
+		Msg:       msg,
+		Data:      nil,
+		RequestID: c.GetString(CtxRequestIDKey),
 	})
 }
 
+// ResponseSuccess 返回数据，/api/v2中的id字段统一返回字符串
 func ResponseSuccess(c *gin.Context, data interface{}) {
+	if data != nil && c.GetInt(CtxAPIVersionKey) >= 2 {
+		var err error
+		if data, err = stringifyIDs(data); err != nil {
+			logger.FromContext(c.Request.Context()).Error("stringifyIDs failed", zap.Error(err))
+			ResponseError(c, CodeServerBusy)
+			return
+		}
+	}
 	c.JSON(http.StatusOK, &ResponseData{
 		Code: CodeSuccess,
-		Msg:  CodeSuccess.Msg(),
+		Msg:  CodeSuccess.MsgIn(getLang(c)),
 		Data: data,
 	})
 }
+
+// errorStatus 为了兼容旧的客户端，只有/api/v2及之后的版本返回错误对应的http状态码
+func errorStatus(c *gin.Context, code ResCode) int {
+	if c.GetInt(CtxAPIVersionKey) < 2 {
+		return http.StatusOK
+	}
+	return code.HTTPStatus()
+}
+
+// stringifyIDs 把data中名为id或以_id结尾的数字字段转换成字符串，避免js解析超过2^53的id时丢失精度
+// /api/v1为了兼容旧的客户端仍然返回数字
+func stringifyIDs(data interface{}) (interface{}, error) {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return convertIDs(v), nil
+}
+
+func convertIDs(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, val := range v {
+			if n, ok := val.(json.Number); ok && (key == "id" || strings.HasSuffix(key, "_id")) {
+				v[key] = n.String()
+				continue
+			}
+			v[key] = convertIDs(val)
+		}
+	case []interface{}:
+		for idx, val := range v {
+			v[idx] = convertIDs(val)
+		}
+	}
+	return v
+}
@@ -0,0 +1,55 @@
+package controller
+
+import (
+	"bluebell/models"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// /api/v1返回数字id，/api/v2返回字符串id
+func TestResponseSuccessIDFormat(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	data := &models.ApiPostDetail{
+		Post:            &models.Post{ID: 1, AuthorID: 2, CommunityID: 3},
+		CommunityDetail: &models.CommunityDetail{ID: 3, OwnerID: 4},
+	}
+	tests := []struct {
+		version int
+		want    map[string]interface{}
+	}{
+		{1, map[string]interface{}{"id": "1", "author_id": 2.0, "community_id": 3.0, "owner_id": "4"}},
+		{2, map[string]interface{}{"id": "1", "author_id": "2", "community_id": "3", "owner_id": "4"}},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("GET", "/", nil)
+		c.Set(CtxAPIVersionKey, tt.version)
+		ResponseSuccess(c, data)
+
+		var resp struct {
+			Data map[string]interface{} `json:"data"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("v%d: unmarshal response failed, err:%v", tt.version, err)
+		}
+		community := resp.Data["community"].(map[string]interface{})
+		got := map[string]interface{}{
+			"id":           resp.Data["id"],
+			"author_id":    resp.Data["author_id"],
+			"community_id": resp.Data["community_id"],
+			"owner_id":     community["owner_id"],
+		}
+		for key, want := range tt.want {
+			if got[key] != want {
+				t.Errorf("v%d: %s = %#v, want %#v", tt.version, key, got[key], want)
+			}
+		}
+		if tt.version >= 2 && community["id"] != "3" {
+			t.Errorf("v%d: community.id = %#v, want \"3\"", tt.version, community["id"])
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package controller
+
+import (
+	"bluebell/logger"
+	"bluebell/logic"
+	"bluebell/models"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// SubscribeHandler 订阅社区
+func SubscribeHandler(c *gin.Context) {
+	cid, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		ResponseError(c, CodeInvalidParam)
+		return
+	}
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		ResponseError(c, CodeNeedLogin)
+		return
+	}
+	if err := logic.Subscribe(c.Request.Context(), userID, cid); err != nil {
+		responseLogicError(c, "logic.Subscribe failed", err)
+		return
+	}
+	ResponseSuccess(c, nil)
+}
+
+// UnsubscribeHandler 取消订阅社区
+func UnsubscribeHandler(c *gin.Context) {
+	cid, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		ResponseError(c, CodeInvalidParam)
+		return
+	}
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		ResponseError(c, CodeNeedLogin)
+		return
+	}
+	if err := logic.Unsubscribe(c.Request.Context(), userID, cid); err != nil {
+		responseLogicError(c, "logic.Unsubscribe failed", err)
+		return
+	}
+	ResponseSuccess(c, nil)
+}
+
+// GetFeedHandler 获取订阅社区的帖子列表
+// GET请求参数(query string)：/api/v1/feed?page=1&size=10&order=time 或 /api/v1/feed?cursor=&size=10
+func GetFeedHandler(c *gin.Context) {
+	p := &models.ParamPostList{
+		Page:  1,
+		Size:  10,
+		Order: models.OrderTime,
+	}
+	if err := c.ShouldBindQuery(p); err != nil {
+		logger.FromContext(c.Request.Context()).Error("GetFeedHandler with invalid params", zap.Error(err))
+		ResponseError(c, CodeInvalidParam)
+		return
+	}
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		ResponseError(c, CodeNeedLogin)
+		return
+	}
+	_, p.UseCursor = c.GetQuery("cursor")
+	data, err := logic.GetFeed(c.Request.Context(), userID, p)
+	if err != nil {
+		responseLogicError(c, "logic.GetFeed() failed", err)
+		return
+	}
+	responsePostList(c, p, data)
+}
@@ -1,11 +1,15 @@
 package controller
 
 import (
-	"bluebell/dao/mysql"
+	"bluebell/logger"
 	"bluebell/logic"
 	"bluebell/models"
+	"bluebell/pkg/jwt"
 	"errors"
 	"fmt"
+	"math"
+	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
@@ -18,24 +22,20 @@ func SignUpHandler(c *gin.Context) {
 	p := new(models.ParamSignUp)
 	if err := c.ShouldBindJSON(p); err != nil {
 		// 请求参数有误，直接返回响应
-		zap.L().Error("SignUp with invalid param", zap.Error(err))
+		logger.FromContext(c.Request.Context()).Error("SignUp with invalid param", zap.Error(err))
 		// 判断err是不是validator.ValidationErrors 类型
 		errs, ok := err.(validator.ValidationErrors)
 		if !ok {
 			ResponseError(c, CodeInvalidParam)
 			return
 		}
-		ResponseErrorWithMsg(c, CodeInvalidParam, removeTopStruct(errs.Translate(trans)))
+		ResponseErrorWithMsg(c, CodeInvalidParam, removeTopStruct(errs.Translate(getTranslator(c))))
 		return
 	}
 	// 业务处理
-	if err := logic.SignUp(p); err != nil {
-		zap.L().Error("logic.SignUp failed", zap.Error(err))
-		if errors.Is(err, mysql.ErrorUserExist) {
-			ResponseError(c, CodeUserExist)
-			return
-		}
-		ResponseError(c, CodeServerBusy)
+	if err := logic.SignUp(c.Request.Context(), p); err != nil {
+		logger.FromContext(c.Request.Context()).Error("logic.SignUp failed", zap.Error(err))
+		ResponseError(c, logicErrorCode(c, err))
 		return
 	}
 	// 3. 返回响应
@@ -49,32 +49,77 @@ func LoginHandler(c *gin.Context) {
 	p := new(models.ParamLogin)
 	if err := c.ShouldBindJSON(p); err != nil {
 		// 请求参数有误，直接返回响应
-		zap.L().Error("Login with invalid param", zap.Error(err))
+		logger.FromContext(c.Request.Context()).Error("Login with invalid param", zap.Error(err))
 		// 判断err是不是validator.ValidationErrors 类型
 		errs, ok := err.(validator.ValidationErrors)
 		if !ok {
 			ResponseError(c, CodeInvalidParam)
 			return
 		}
-		ResponseErrorWithMsg(c, CodeInvalidParam, removeTopStruct(errs.Translate(trans)))
+		ResponseErrorWithMsg(c, CodeInvalidParam, removeTopStruct(errs.Translate(getTranslator(c))))
 		return
 	}
 	// 2.业务逻辑处理
-	user, err := logic.Login(p)
+	user, err := logic.Login(c.Request.Context(), p, c.ClientIP())
 	if err != nil {
-		zap.L().Error("logic.Login failed", zap.String("username", p.Username), zap.Error(err))
-		if errors.Is(err, mysql.ErrorUserNotExist) {
-			ResponseError(c, CodeUserNotExist)
+		logger.FromContext(c.Request.Context()).Error("logic.Login failed", zap.String("username", p.Username), zap.Error(err))
+		var locked *logic.LoginLockedError
+		if errors.As(err, &locked) {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(locked.RetryAfter.Seconds()))))
+			ResponseErrorWithStatus(c, http.StatusTooManyRequests, CodeLoginLocked)
 			return
 		}
-		ResponseError(c, CodeInvalidPassword)
+		ResponseError(c, logicErrorCode(c, err))
 		return
 	}
 
 	// 3.返回响应
+	responseToken(c, user)
+}
+
+// RefreshTokenHandler 使用refresh token换取新的token
+func RefreshTokenHandler(c *gin.Context) {
+	p := new(models.ParamRefreshToken)
+	if err := c.ShouldBindJSON(p); err != nil {
+		logger.FromContext(c.Request.Context()).Error("RefreshToken with invalid param", zap.Error(err))
+		ResponseError(c, CodeInvalidParam)
+		return
+	}
+	user, err := logic.RefreshToken(c.Request.Context(), p.RefreshToken)
+	if err != nil {
+		responseLogicError(c, "logic.RefreshToken failed", err)
+		return
+	}
+	responseToken(c, user)
+}
+
+// LogoutHandler 退出登录，当前用户已签发的token全部失效
+func LogoutHandler(c *gin.Context) {
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		ResponseError(c, CodeNeedLogin)
+		return
+	}
+	if err := logic.Logout(c.Request.Context(), userID); err != nil {
+		responseLogicError(c, "logic.Logout failed", err)
+		return
+	}
+	ResponseSuccess(c, nil)
+}
+
+// responseToken 返回用户信息及签发的token
+func responseToken(c *gin.Context, user *models.User) {
 	ResponseSuccess(c, gin.H{
-		"user_id":   fmt.Sprintf("%d", user.UserID), // id值大于1<<53-1  int64类型的最大值是1<<63-1
-		"user_name": user.Username,
-		"token":     user.Token,
+		"user_id":       fmt.Sprintf("%d", user.UserID), // id值大于1<<53-1  int64类型的最大值是1<<63-1
+		"user_name":     user.Username,
+		"token":         user.Token,
+		"refresh_token": user.RefreshToken,
 	})
-}
\ No newline at end of file
+}
+
+// JWKSHandler 公开签名token使用的公钥，其他服务据此校验token
+// 只包含RS256/EdDSA的key，HS256的密钥不会公开
+func JWKSHandler(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, gin.H{"keys": jwt.JWKS()})
+}
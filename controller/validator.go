@@ -2,25 +2,53 @@ package controller
 
 import (
 	"bluebell/models"
+	"bluebell/pkg/i18n"
 	"fmt"
 	"reflect"
 	"strings"
 
+	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/locales"
 	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/es"
+	"github.com/go-playground/locales/fr"
+	"github.com/go-playground/locales/ja"
 	"github.com/go-playground/locales/zh"
+	"github.com/go-playground/locales/zh_Hant_TW"
+	"golang.org/x/text/language"
 
 	ut "github.com/go-playground/universal-translator"
 	"github.com/go-playground/validator/v10"
 	enTranslations "github.com/go-playground/validator/v10/translations/en"
+	esTranslations "github.com/go-playground/validator/v10/translations/es"
+	frTranslations "github.com/go-playground/validator/v10/translations/fr"
+	jaTranslations "github.com/go-playground/validator/v10/translations/ja"
 	zhTranslations "github.com/go-playground/validator/v10/translations/zh"
+	zhTwTranslations "github.com/go-playground/validator/v10/translations/zh_tw"
 )
 
-// 定义一个全局翻译器
-var trans ut.Translator
+// validatorLocale 校验错误提示支持的语言
+type validatorLocale struct {
+	locale   locales.Translator
+	register func(v *validator.Validate, trans ut.Translator) error
+}
+
+// validatorLocales key与消息文件的语言名一致
+var validatorLocales = map[string]validatorLocale{
+	"en":    {en.New(), enTranslations.RegisterDefaultTranslations},
+	"zh":    {zh.New(), zhTranslations.RegisterDefaultTranslations},
+	"zh-TW": {zh_Hant_TW.New(), zhTwTranslations.RegisterDefaultTranslations},
+	"ja":    {ja.New(), jaTranslations.RegisterDefaultTranslations},
+	"fr":    {fr.New(), frTranslations.RegisterDefaultTranslations},
+	"es":    {es.New(), esTranslations.RegisterDefaultTranslations},
+}
 
-// InitTrans 初始化翻译器
-func InitTrans(locale string) (err error) {
+// 每种语言的翻译器
+var translators = make(map[string]ut.Translator, len(validatorLocales))
+
+// InitTrans 初始化所有语言的翻译器，每个请求根据语言选择翻译器
+func InitTrans() (err error) {
 	// 修改gin框架中的Validator引擎属性，实现自定制
 	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
 		// 注册一个获取json tag的自定义方法
@@ -34,35 +62,40 @@ func InitTrans(locale string) (err error) {
 		// 为SignUpParam注册自定义校验方法
 		v.RegisterStructValidation(SignUpParamStructLevelValidation, models.ParamSignUp{})
 
-		zhT := zh.New()
-		enT := en.New()
-
-		// 第一个参数是备用（fallback）的语言环境
-		// 后面的参数是应该支持的语言环境（支持多个）
-		// uni := ut.New(zhT, zhT) 也是可以的
-		uni := ut.New(enT, zhT, enT)
-
-		// locale 通常取决于 http 请求头的 'Accept-Language'
-		var ok bool
-		// 也可以使用 uni.FindTranslator(...) 传入多个locale进行查找
-		trans, ok = uni.GetTranslator(locale)
-		if !ok {
-			return fmt.Errorf("uni.GetTranslator(%s) failed", locale)
+		// 第一个参数是备用（fallback）的语言环境，后面的参数是应该支持的语言环境
+		supported := make([]locales.Translator, 0, len(validatorLocales))
+		for _, l := range validatorLocales {
+			supported = append(supported, l.locale)
 		}
-		// 注册翻译器
-		switch locale {
-		case "en":
-			err = enTranslations.RegisterDefaultTranslations(v, trans)
-		case "zh":
-			err = zhTranslations.RegisterDefaultTranslations(v, trans)
-		default:
-			err = enTranslations.RegisterDefaultTranslations(v, trans)
+		uni := ut.New(en.New(), supported...)
+		for lang, l := range validatorLocales {
+			trans, ok := uni.GetTranslator(l.locale.Locale())
+			if !ok {
+				return fmt.Errorf("uni.GetTranslator(%s) failed", l.locale.Locale())
+			}
+			// 注册翻译器
+			if err = l.register(v, trans); err != nil {
+				return fmt.Errorf("register %s translations failed: %w", lang, err)
+			}
+			translators[lang] = trans
 		}
-		return
 	}
 	return
 }
 
+// getTranslator 获取请求的语言对应的翻译器
+// 没有该语言的翻译时依次使用同一语种(如zh-HK使用zh)、默认语言、英语的翻译器
+func getTranslator(c *gin.Context) ut.Translator {
+	lang := getLang(c)
+	base, _ := language.Make(lang).Base()
+	for _, l := range []string{lang, base.String(), i18n.Default(), "en"} {
+		if trans, ok := translators[l]; ok {
+			return trans
+		}
+	}
+	return nil
+}
+
 // removeTopStruct 去除提示信息中的结构体名称
 func removeTopStruct(fields map[string]string) map[string]string {
 	res := map[string]string{}
@@ -6,7 +6,6 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
-	"go.uber.org/zap"
 )
 
 // 投票
@@ -26,7 +25,7 @@ func PostVoteController(c *gin.Context) {
 			ResponseError(c, CodeInvalidParam)
 			return
 		}
-		errData := removeTopStruct(errs.Translate(trans)) // 翻译并去除掉错误提示中的结构体标识
+		errData := removeTopStruct(errs.Translate(getTranslator(c))) // 翻译并去除掉错误提示中的结构体标识
 		ResponseErrorWithMsg(c, CodeInvalidParam, errData)
 		return
 	}
@@ -37,9 +36,8 @@ func PostVoteController(c *gin.Context) {
 		return
 	}
 	// 具体投票的业务逻辑
-	if err := logic.VoteForPost(userID, p); err != nil {
-		zap.L().Error("logic.VoteForPost() failed", zap.Error(err))
-		ResponseError(c, CodeServerBusy)
+	if err := logic.VoteForPost(c.Request.Context(), userID, p); err != nil {
+		responseLogicError(c, "logic.VoteForPost() failed", err)
 		return
 	}
 
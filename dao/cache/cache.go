@@ -0,0 +1,222 @@
+package cache
+
+import (
+	"bluebell/dao/redis"
+	"bluebell/logger"
+	"bluebell/setting"
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+)
+
+// 读取顺序: 本地缓存 -> redis -> mysql
+// mysql中的数据被修改后，调用Invalidate*删除redis中的缓存并通知所有实例删除本地缓存
+
+var (
+	local    = newLRU(0, 0)
+	redisTTL time.Duration
+	group    singleflight.Group
+	stats    counters
+)
+
+type counters struct {
+	localHits atomic.Int64
+	redisHits atomic.Int64
+	misses    atomic.Int64
+}
+
+// Stats 缓存命中情况
+type Stats struct {
+	LocalHits int64 `json:"local_hits"` // 本地缓存命中次数
+	RedisHits int64 `json:"redis_hits"` // redis缓存命中次数
+	Misses    int64 `json:"misses"`     // 未命中，回源到mysql的次数
+	LocalSize int   `json:"local_size"` // 本地缓存当前的条目数
+}
+
+// Init 初始化缓存
+func Init(cfg *setting.CacheConfig) {
+	local = newLRU(cfg.LocalSize, time.Duration(cfg.LocalTTL)*time.Second)
+	redisTTL = time.Duration(cfg.RedisTTL) * time.Second
+}
+
+// GetStats 获取缓存命中情况
+func GetStats() Stats {
+	return Stats{
+		LocalHits: stats.localHits.Load(),
+		RedisHits: stats.redisHits.Load(),
+		Misses:    stats.misses.Load(),
+		LocalSize: local.len(),
+	}
+}
+
+// RunInvalidationListener 接收其他实例发出的失效通知，删除本地缓存
+// 订阅断开(包括redis客户端因配置修改被替换)后会重新订阅，直到ctx被取消
+func RunInvalidationListener(ctx context.Context) {
+	for {
+		err := redis.WatchCacheInvalidation(ctx, local.remove)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			logger.FromContext(ctx).Error("watch cache invalidation failed", zap.Error(err))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func invalidate(ctx context.Context, keys ...string) error {
+	for _, key := range keys {
+		local.remove(key)
+	}
+	return redis.DelCaches(ctx, keys...)
+}
+
+// cacheVersion 缓存的数据是models的json序列化结果，json格式变化时修改版本，不再读取旧格式的缓存
+const cacheVersion = "v3"
+
+func cacheKey(kind string, id int64) string {
+	return kind + ":" + cacheVersion + ":" + strconv.FormatInt(id, 10)
+}
+
+// getOne 读取一条数据，缓存都未命中时调用load从mysql加载
+// 同一个key同时只会有一个请求回源
+func getOne[T any](ctx context.Context, key string, load func(context.Context) (T, error)) (v T, err error) {
+	if b, ok := local.get(key); ok {
+		stats.localHits.Add(1)
+		err = json.Unmarshal(b, &v)
+		return
+	}
+	found, versions, err := redis.GetCaches(ctx, []string{key})
+	if err != nil {
+		// redis不可用时直接回源
+		logger.FromContext(ctx).Warn("redis.GetCaches failed", zap.String("key", key), zap.Error(err))
+	}
+	if b, ok := found[key]; ok {
+		stats.redisHits.Add(1)
+		local.set(key, b)
+		err = json.Unmarshal(b, &v)
+		return
+	}
+
+	stats.misses.Add(1)
+	// 回源的结果由等待同一个key的所有请求共享，不能因为发起回源的请求被取消而失败
+	// 每个请求的ctx只结束它自己的等待
+	loadCtx := context.WithoutCancel(ctx)
+	ch := group.DoChan(key, func() (interface{}, error) {
+		v, err := load(loadCtx)
+		if err != nil {
+			return nil, err
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		store(loadCtx, map[string][]byte{key: b}, versions)
+		return b, nil
+	})
+	select {
+	case <-ctx.Done():
+		err = ctx.Err()
+	case ret := <-ch:
+		if err = ret.Err; err != nil {
+			return
+		}
+		err = json.Unmarshal(ret.Val.([]byte), &v)
+	}
+	return
+}
+
+// getMany 批量读取数据，只把缓存都未命中的id交给load从mysql批量加载
+// 不存在的id不会出现在返回结果中
+func getMany[T any](ctx context.Context, kind string, ids []int64, idOf func(T) int64,
+	load func(context.Context, []int64) ([]T, error)) (map[int64]T, error) {
+	result := make(map[int64]T, len(ids))
+	missing := make([]int64, 0, len(ids))
+	missingKeys := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if _, ok := result[id]; ok {
+			continue
+		}
+		key := cacheKey(kind, id)
+		b, ok := local.get(key)
+		if !ok {
+			missing = append(missing, id)
+			missingKeys = append(missingKeys, key)
+			continue
+		}
+		var v T
+		if err := json.Unmarshal(b, &v); err != nil {
+			return nil, err
+		}
+		stats.localHits.Add(1)
+		result[id] = v
+	}
+	if len(missing) == 0 {
+		return result, nil
+	}
+
+	found, versions, err := redis.GetCaches(ctx, missingKeys)
+	if err != nil {
+		logger.FromContext(ctx).Warn("redis.GetCaches failed", zap.String("kind", kind), zap.Error(err))
+	}
+	ids, missing = missing, missing[:0:0]
+	for idx, id := range ids {
+		b, ok := found[missingKeys[idx]]
+		if !ok {
+			missing = append(missing, id)
+			continue
+		}
+		var v T
+		if err := json.Unmarshal(b, &v); err != nil {
+			return nil, err
+		}
+		stats.redisHits.Add(1)
+		local.set(missingKeys[idx], b)
+		result[id] = v
+	}
+	if len(missing) == 0 {
+		return result, nil
+	}
+
+	stats.misses.Add(int64(len(missing)))
+	// 请求被取消时也完成加载并写入缓存，避免慢查询一直回源
+	ctx = context.WithoutCancel(ctx)
+	loaded, err := load(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+	items := make(map[string][]byte, len(loaded))
+	for _, v := range loaded {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		items[cacheKey(kind, idOf(v))] = b
+		result[idOf(v)] = v
+	}
+	store(ctx, items, versions)
+	return result, nil
+}
+
+// store 写入redis和本地缓存，写redis失败不影响本次请求
+// versions是回源前读取的版本号，回源期间缓存被删除过的数据可能已经过时，不写入
+// 只有写入redis成功的数据才写入本地缓存，redis不可用时也收不到其他实例的失效通知
+func store(ctx context.Context, items map[string][]byte, versions map[string]string) {
+	stored, err := redis.SetCaches(ctx, items, versions, redisTTL)
+	if err != nil {
+		logger.FromContext(ctx).Warn("redis.SetCaches failed", zap.Error(err))
+		return
+	}
+	for _, key := range stored {
+		local.set(key, items[key])
+	}
+}
@@ -0,0 +1,110 @@
+package cache
+
+import (
+	"bluebell/dao/redis"
+	"bluebell/setting"
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func setupCache(t *testing.T) *miniredis.Miniredis {
+	mr := miniredis.RunT(t)
+	port, _ := strconv.Atoi(mr.Port())
+	if err := redis.Init(&setting.RedisConfig{Host: mr.Host(), Port: port, PoolSize: 1}); err != nil {
+		t.Fatalf("redis.Init failed, err:%v", err)
+	}
+	t.Cleanup(redis.Close)
+	Init(&setting.CacheConfig{LocalSize: 10, LocalTTL: 60, RedisTTL: 60})
+	return mr
+}
+
+type item struct {
+	ID int64 `json:"id"`
+}
+
+// 发起回源的请求被取消后，等待同一个key的其他请求仍能拿到结果，结果也会写入缓存
+func TestGetOneLoaderSurvivesCanceledCaller(t *testing.T) {
+	setupCache(t)
+	key := cacheKey("test", 1)
+	started, release := make(chan struct{}), make(chan struct{})
+	load := func(ctx context.Context) (*item, error) {
+		close(started)
+		<-release
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return &item{ID: 1}, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := getOne(ctx, key, load)
+		firstErr <- err
+	}()
+	<-started
+
+	second := make(chan *item, 1)
+	go func() {
+		v, err := getOne(context.Background(), key, func(context.Context) (*item, error) {
+			return nil, errors.New("should share the first load")
+		})
+		if err != nil {
+			t.Errorf("second getOne failed, err:%v", err)
+		}
+		second <- v
+	}()
+
+	cancel()
+	if err := <-firstErr; !errors.Is(err, context.Canceled) {
+		t.Fatalf("first getOne err:%v, want context.Canceled", err)
+	}
+	close(release)
+	select {
+	case v := <-second:
+		if v == nil || v.ID != 1 {
+			t.Fatalf("second getOne got %+v, want id 1", v)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("second getOne did not return")
+	}
+	if _, ok := local.get(key); !ok {
+		t.Fatal("loaded value should be cached")
+	}
+}
+
+// 回源期间缓存被删除时，读到的旧数据不写入缓存
+func TestGetOneSkipsStoreAfterInvalidation(t *testing.T) {
+	mr := setupCache(t)
+	key := cacheKey("test", 2)
+	ctx := context.Background()
+	v, err := getOne(ctx, key, func(context.Context) (*item, error) {
+		// 模拟回源期间数据被修改
+		if err := invalidate(ctx, key); err != nil {
+			t.Fatalf("invalidate failed, err:%v", err)
+		}
+		return &item{ID: 2}, nil
+	})
+	if err != nil || v.ID != 2 {
+		t.Fatalf("getOne got %+v, err:%v", v, err)
+	}
+	if _, ok := local.get(key); ok {
+		t.Fatal("stale value should not be cached locally")
+	}
+	if mr.Exists(redis.Prefix + "cache:" + key) {
+		t.Fatal("stale value should not be cached in redis")
+	}
+
+	// 没有新的修改时正常写入缓存
+	if _, err = getOne(ctx, key, func(context.Context) (*item, error) { return &item{ID: 2}, nil }); err != nil {
+		t.Fatalf("getOne failed, err:%v", err)
+	}
+	if !mr.Exists(redis.Prefix + "cache:" + key) {
+		t.Fatal("value should be cached in redis")
+	}
+}
@@ -0,0 +1,71 @@
+package cache
+
+import (
+	"bluebell/dao/mysql"
+	"bluebell/models"
+	"context"
+	"strconv"
+)
+
+const (
+	kindUser      = "user"
+	kindCommunity = "community"
+	kindPost      = "post"
+)
+
+// GetUserByID 根据id获取用户信息
+func GetUserByID(ctx context.Context, uid int64) (*models.User, error) {
+	return getOne(ctx, cacheKey(kindUser, uid), func(ctx context.Context) (*models.User, error) {
+		return mysql.GetUserById(ctx, uid)
+	})
+}
+
+// GetUsersByIDs 根据id列表批量获取用户信息
+func GetUsersByIDs(ctx context.Context, ids []int64) (map[int64]*models.User, error) {
+	return getMany(ctx, kindUser, ids, func(u *models.User) int64 { return u.UserID }, mysql.GetUsersByIDs)
+}
+
+// InvalidateUser 用户信息修改后删除缓存
+func InvalidateUser(ctx context.Context, uid int64) error {
+	return invalidate(ctx, cacheKey(kindUser, uid))
+}
+
+// GetCommunityDetailByID 根据id获取社区详情
+func GetCommunityDetailByID(ctx context.Context, id int64) (*models.CommunityDetail, error) {
+	return getOne(ctx, cacheKey(kindCommunity, id), func(ctx context.Context) (*models.CommunityDetail, error) {
+		return mysql.GetCommunityDetailByID(ctx, id)
+	})
+}
+
+// GetCommunitiesByIDs 根据id列表批量获取社区详情
+func GetCommunitiesByIDs(ctx context.Context, ids []int64) (map[int64]*models.CommunityDetail, error) {
+	return getMany(ctx, kindCommunity, ids, func(c *models.CommunityDetail) int64 { return c.ID }, mysql.GetCommunitiesByIDs)
+}
+
+// InvalidateCommunity 社区信息修改后删除缓存
+func InvalidateCommunity(ctx context.Context, id int64) error {
+	return invalidate(ctx, cacheKey(kindCommunity, id))
+}
+
+// GetPostByID 根据id获取帖子
+func GetPostByID(ctx context.Context, pid int64) (*models.Post, error) {
+	return getOne(ctx, cacheKey(kindPost, pid), func(ctx context.Context) (*models.Post, error) {
+		return mysql.GetPostById(ctx, pid)
+	})
+}
+
+// GetPostsByIDs 根据id列表批量获取帖子，已删除的帖子不在结果中
+func GetPostsByIDs(ctx context.Context, ids []int64) (map[int64]*models.Post, error) {
+	return getMany(ctx, kindPost, ids, func(p *models.Post) int64 { return p.ID }, func(ctx context.Context, ids []int64) ([]*models.Post, error) {
+		strIDs := make([]string, 0, len(ids))
+		for _, id := range ids {
+			strIDs = append(strIDs, strconv.FormatInt(id, 10))
+		}
+		return mysql.GetPostListByIDs(ctx, strIDs)
+	})
+}
+
+// InvalidatePost 帖子修改、删除或状态变化后删除缓存
+func InvalidatePost(ctx context.Context, pid int64) error {
+	return invalidate(ctx, cacheKey(kindPost, pid))
+}
@@ -0,0 +1,40 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLRUEvictsLeastRecentlyUsed(t *testing.T) {
+	c := newLRU(2, time.Minute)
+	c.set("a", []byte("1"))
+	c.set("b", []byte("2"))
+	// 访问a之后b成为最久未使用的数据
+	if _, ok := c.get("a"); !ok {
+		t.Fatal("a should be cached")
+	}
+	c.set("c", []byte("3"))
+	if _, ok := c.get("b"); ok {
+		t.Fatal("b should be evicted")
+	}
+	if _, ok := c.get("a"); !ok {
+		t.Fatal("a should still be cached")
+	}
+	if c.len() != 2 {
+		t.Fatalf("len = %d, want 2", c.len())
+	}
+}
+
+func TestLRUExpire(t *testing.T) {
+	c := newLRU(2, 10*time.Millisecond)
+	c.set("a", []byte("1"))
+	time.Sleep(20 * time.Millisecond)
+	if _, ok := c.get("a"); ok {
+		t.Fatal("a should be expired")
+	}
+	c.set("b", []byte("2"))
+	c.remove("b")
+	if _, ok := c.get("b"); ok {
+		t.Fatal("b should be removed")
+	}
+}
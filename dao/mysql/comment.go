@@ -0,0 +1,127 @@
+package mysql
+
+import (
+	"bluebell/models"
+	"bluebell/pkg/tracing"
+	"context"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// CreateComment 创建评论
+func CreateComment(ctx context.Context, c *models.Comment) (err error) {
+	ctx, span := tracing.Start(ctx, "mysql.CreateComment")
+	defer func() { tracing.End(span, err) }()
+	sqlStr := `insert into comment(
+	comment_id, post_id, parent_id, author_id, content)
+	values (?, ?, ?, ?, ?)`
+	_, err = db.ExecContext(ctx, sqlStr, c.ID, c.PostID, c.ParentID, c.AuthorID, c.Content)
+	return
+}
+
+// GetCommentByID 根据id查询单条评论
+func GetCommentByID(ctx context.Context, id int64) (comment *models.Comment, err error) {
+	ctx, span := tracing.Start(ctx, "mysql.GetCommentByID")
+	defer func() { tracing.End(span, err) }()
+	comment = new(models.Comment)
+	sqlStr := `select comment_id, post_id, parent_id, author_id, content, create_time
+	from comment
+	where comment_id = ?`
+	err = db.GetContext(ctx, comment, sqlStr, id)
+	if err == sql.ErrNoRows {
+		err = ErrorInvalidID
+	}
+	return
+}
+
+// GetCommentIDsByParent 查询对帖子或某条评论的直接回复的id，按时间从新到旧排序
+func GetCommentIDsByParent(ctx context.Context, postID, parentID int64) (ids []int64, err error) {
+	ctx, span := tracing.Start(ctx, "mysql.GetCommentIDsByParent")
+	defer func() { tracing.End(span, err) }()
+	sqlStr := `select comment_id
+	from comment
+	where post_id = ? and parent_id = ?
+	order by create_time desc`
+	err = db.SelectContext(ctx, &ids, sqlStr, postID, parentID)
+	return
+}
+
+// GetCommentsByIDs 根据id列表查询评论，按给定的id顺序返回，不存在的id会被忽略
+func GetCommentsByIDs(ctx context.Context, ids []int64) (comments []*models.Comment, err error) {
+	ctx, span := tracing.Start(ctx, "mysql.GetCommentsByIDs")
+	defer func() { tracing.End(span, err) }()
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	sqlStr := `select comment_id, post_id, parent_id, author_id, content, create_time
+	from comment
+	where comment_id in (?)`
+	query, args, err := sqlx.In(sqlStr, ids)
+	if err != nil {
+		return nil, err
+	}
+	var list []*models.Comment
+	if err = db.SelectContext(ctx, &list, db.Rebind(query), args...); err != nil {
+		return nil, err
+	}
+	byID := make(map[int64]*models.Comment, len(list))
+	for _, c := range list {
+		byID[c.ID] = c
+	}
+	comments = make([]*models.Comment, 0, len(list))
+	for _, id := range ids {
+		if c, ok := byID[id]; ok {
+			comments = append(comments, c)
+		}
+	}
+	return
+}
+
+// GetCommentsByParentIDs 查询帖子下对指定评论的直接回复，按时间从新到旧排序
+func GetCommentsByParentIDs(ctx context.Context, postID int64, parentIDs []int64) (comments []*models.Comment, err error) {
+	ctx, span := tracing.Start(ctx, "mysql.GetCommentsByParentIDs")
+	defer func() { tracing.End(span, err) }()
+	if len(parentIDs) == 0 {
+		return nil, nil
+	}
+	sqlStr := `select comment_id, post_id, parent_id, author_id, content, create_time
+	from comment
+	where post_id = ? and parent_id in (?)
+	order by create_time desc`
+	query, args, err := sqlx.In(sqlStr, postID, parentIDs)
+	if err != nil {
+		return nil, err
+	}
+	err = db.SelectContext(ctx, &comments, db.Rebind(query), args...)
+	return
+}
+
+// CountCommentReplies 统计帖子下每条评论的直接回复数，没有回复的评论不在结果中
+func CountCommentReplies(ctx context.Context, postID int64, parentIDs []int64) (counts map[int64]int, err error) {
+	ctx, span := tracing.Start(ctx, "mysql.CountCommentReplies")
+	defer func() { tracing.End(span, err) }()
+	counts = make(map[int64]int, len(parentIDs))
+	if len(parentIDs) == 0 {
+		return
+	}
+	sqlStr := `select parent_id, count(*) as num
+	from comment
+	where post_id = ? and parent_id in (?)
+	group by parent_id`
+	query, args, err := sqlx.In(sqlStr, postID, parentIDs)
+	if err != nil {
+		return nil, err
+	}
+	var rows []struct {
+		ParentID int64 `db:"parent_id"`
+		Num      int   `db:"num"`
+	}
+	if err = db.SelectContext(ctx, &rows, db.Rebind(query), args...); err != nil {
+		return nil, err
+	}
+	for _, r := range rows {
+		counts[r.ParentID] = r.Num
+	}
+	return
+}
@@ -1,17 +1,27 @@
 package mysql
 
 import (
+	"bluebell/logger"
 	"bluebell/models"
+	"bluebell/pkg/tracing"
+	"context"
 	"database/sql"
+	"errors"
 
-	"go.uber.org/zap"
+	gomysql "github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
 )
 
-func GetCommunityList() (communityList []*models.Community, err error) {
-	sqlStr := "select community_id,community_name from community"
-	if err := db.Select(&communityList, sqlStr); err != nil {
+// mysql唯一索引冲突的错误码
+const errDuplicateEntry = 1062
+
+func GetCommunityList(ctx context.Context) (communityList []*models.Community, err error) {
+	ctx, span := tracing.Start(ctx, "mysql.GetCommunityList")
+	defer func() { tracing.End(span, err) }()
+	sqlStr := "select community_id,community_name from community where status = ?"
+	if err := db.SelectContext(ctx, &communityList, sqlStr, models.CommunityStatusNormal); err != nil {
 		if err == sql.ErrNoRows {
-			zap.L().Warn("there is no community in db")
+			logger.FromContext(ctx).Warn("there is no community in db")
 			err = nil
 		}
 	}
@@ -19,13 +29,100 @@ func GetCommunityList() (communityList []*models.Community, err error) {
 }
 
 // GetCommunityDetailByID 根据Id查询社区详情
-func GetCommunityDetailByID(id int64) (commity *models.CommunityDetail, err error) {
+func GetCommunityDetailByID(ctx context.Context, id int64) (commity *models.CommunityDetail, err error) {
+	ctx, span := tracing.Start(ctx, "mysql.GetCommunityDetailByID")
+	defer func() { tracing.End(span, err) }()
 	commity = new(models.CommunityDetail)
-	sqlStr := "select community_id,community_name,introduction,create_time from community where community_id = ?"
-	if err := db.Get(commity, sqlStr, id); err != nil {
+	sqlStr := "select community_id,community_name,introduction,owner_id,status,create_time from community where community_id = ?"
+	if err = db.GetContext(ctx, commity, sqlStr, id); err != nil {
 		if err == sql.ErrNoRows {
 			err = ErrorInvalidID
 		}
 	}
 	return commity, err
 }
+
+// GetCommunitiesByIDs 根据id列表批量查询社区详情
+func GetCommunitiesByIDs(ctx context.Context, ids []int64) (communities []*models.CommunityDetail, err error) {
+	ctx, span := tracing.Start(ctx, "mysql.GetCommunitiesByIDs")
+	defer func() { tracing.End(span, err) }()
+	if len(ids) == 0 {
+		return
+	}
+	sqlStr := "select community_id,community_name,introduction,owner_id,status,create_time from community where community_id in (?)"
+	query, args, err := sqlx.In(sqlStr, ids)
+	if err != nil {
+		return nil, err
+	}
+	query = db.Rebind(query)
+	err = db.SelectContext(ctx, &communities, query, args...)
+	return
+}
+
+// CheckCommunityNameExist 检查社区名称是否已被其他社区使用
+func CheckCommunityNameExist(ctx context.Context, name string, excludeID int64) (err error) {
+	ctx, span := tracing.Start(ctx, "mysql.CheckCommunityNameExist")
+	defer func() { tracing.End(span, err) }()
+	sqlStr := `select count(community_id) from community where community_name = ? and community_id != ?`
+	var count int64
+	if err := db.GetContext(ctx, &count, sqlStr, name, excludeID); err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrorCommunityExist
+	}
+	return
+}
+
+// CreateCommunity 创建社区，创建人同时成为该社区的版主
+func CreateCommunity(ctx context.Context, c *models.CommunityDetail) (err error) {
+	ctx, span := tracing.Start(ctx, "mysql.CreateCommunity")
+	defer func() { tracing.End(span, err) }()
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+		err = tx.Commit()
+	}()
+
+	sqlStr := `insert into community(community_id, community_name, introduction, owner_id) values (?, ?, ?, ?)`
+	if _, err = tx.ExecContext(ctx, sqlStr, c.ID, c.Name, c.Introduction, c.OwnerID); err != nil {
+		err = duplicateToExist(err)
+		return
+	}
+	sqlStr = `insert ignore into community_moderator(community_id, user_id) values (?, ?)`
+	_, err = tx.ExecContext(ctx, sqlStr, c.ID, c.OwnerID)
+	return
+}
+
+// UpdateCommunity 修改社区名称和简介
+func UpdateCommunity(ctx context.Context, c *models.CommunityDetail) (err error) {
+	ctx, span := tracing.Start(ctx, "mysql.UpdateCommunity")
+	defer func() { tracing.End(span, err) }()
+	sqlStr := `update community set community_name = ?, introduction = ? where community_id = ?`
+	_, err = db.ExecContext(ctx, sqlStr, c.Name, c.Introduction, c.ID)
+	return duplicateToExist(err)
+}
+
+// SetCommunityStatus 修改社区状态
+func SetCommunityStatus(ctx context.Context, id int64, status int8) (err error) {
+	ctx, span := tracing.Start(ctx, "mysql.SetCommunityStatus")
+	defer func() { tracing.End(span, err) }()
+	sqlStr := `update community set status = ? where community_id = ?`
+	_, err = db.ExecContext(ctx, sqlStr, status, id)
+	return
+}
+
+// duplicateToExist 并发创建同名社区时由唯一索引兜底
+func duplicateToExist(err error) error {
+	var me *gomysql.MySQLError
+	if errors.As(err, &me) && me.Number == errDuplicateEntry {
+		return ErrorCommunityExist
+	}
+	return err
+}
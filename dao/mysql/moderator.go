@@ -0,0 +1,48 @@
+package mysql
+
+import (
+	"bluebell/pkg/tracing"
+	"context"
+)
+
+// AddCommunityModerator 指派社区版主，重复指派不报错
+func AddCommunityModerator(ctx context.Context, communityID, userID int64) (err error) {
+	ctx, span := tracing.Start(ctx, "mysql.AddCommunityModerator")
+	defer func() { tracing.End(span, err) }()
+	sqlStr := `insert ignore into community_moderator(community_id, user_id) values (?, ?)`
+	_, err = db.ExecContext(ctx, sqlStr, communityID, userID)
+	return
+}
+
+// RemoveCommunityModerator 撤销社区版主
+func RemoveCommunityModerator(ctx context.Context, communityID, userID int64) (err error) {
+	ctx, span := tracing.Start(ctx, "mysql.RemoveCommunityModerator")
+	defer func() { tracing.End(span, err) }()
+	sqlStr := `delete from community_moderator where community_id = ? and user_id = ?`
+	ret, err := db.ExecContext(ctx, sqlStr, communityID, userID)
+	if err != nil {
+		return
+	}
+	return checkAffected(ret)
+}
+
+// IsCommunityModerator 判断用户是否是社区的版主
+func IsCommunityModerator(ctx context.Context, communityID, userID int64) (_ bool, err error) {
+	ctx, span := tracing.Start(ctx, "mysql.IsCommunityModerator")
+	defer func() { tracing.End(span, err) }()
+	sqlStr := `select count(1) from community_moderator where community_id = ? and user_id = ?`
+	var count int64
+	if err := db.GetContext(ctx, &count, sqlStr, communityID, userID); err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// CountModeratedCommunities 查询用户担任版主的社区数量
+func CountModeratedCommunities(ctx context.Context, userID int64) (count int64, err error) {
+	ctx, span := tracing.Start(ctx, "mysql.CountModeratedCommunities")
+	defer func() { tracing.End(span, err) }()
+	sqlStr := `select count(1) from community_moderator where user_id = ?`
+	err = db.GetContext(ctx, &count, sqlStr, userID)
+	return
+}
@@ -2,10 +2,13 @@ package mysql
 
 import (
 	"bluebell/setting"
+	"context"
+	"database/sql"
 	"fmt"
 
 	_ "github.com/go-sql-driver/mysql" // init()
 	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
 )
 
 var db *sqlx.DB
@@ -19,10 +22,46 @@ func Init(cfg *setting.MySQLConfig) (err error) {
 	}
 	db.SetMaxOpenConns(cfg.MaxOpenConns)
 	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	setting.OnChange(reload)
 	return
 }
 
+// reload 配置修改后更新连接池参数，连接地址等修改需要重启生效
+func reload(oldCfg, newCfg *setting.AppConfig) {
+	o, n := oldCfg.MySQLConfig, newCfg.MySQLConfig
+	if o == nil || n == nil || o.MaxOpenConns == n.MaxOpenConns && o.MaxIdleConns == n.MaxIdleConns {
+		return
+	}
+	db.SetMaxOpenConns(n.MaxOpenConns)
+	db.SetMaxIdleConns(n.MaxIdleConns)
+	zap.L().Info("mysql pool reloaded",
+		zap.Int("max_open_conns", n.MaxOpenConns),
+		zap.Int("max_idle_conns", n.MaxIdleConns))
+}
+
 // Close 关闭MySQL连接
 func Close() {
 	_ = db.Close()
 }
+
+// checkAffected 更新语句没有命中任何记录时返回ErrorInvalidID
+func checkAffected(ret sql.Result) error {
+	n, err := ret.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrorInvalidID
+	}
+	return nil
+}
+
+// Stats 连接池的统计数据
+func Stats() sql.DBStats {
+	return db.Stats()
+}
+
+// Ping 检查数据库连接是否可用
+func Ping(ctx context.Context) error {
+	return db.PingContext(ctx)
+}
@@ -2,60 +2,145 @@ package mysql
 
 import (
 	"bluebell/models"
+	"bluebell/pkg/tracing"
+	"context"
+	"database/sql"
 	"strings"
 
 	"github.com/jmoiron/sqlx"
 )
 
 // CreatePost 创建帖子
-func CreatePost(p *models.Post) (err error) {
+func CreatePost(ctx context.Context, p *models.Post) (err error) {
+	ctx, span := tracing.Start(ctx, "mysql.CreatePost")
+	defer func() { tracing.End(span, err) }()
 	sqlStr := `insert into post(
 		post_id, title, content, author_id, community_id)
 		values (?, ?, ?, ?, ?)
 		`
-	_, err = db.Exec(sqlStr, p.ID, p.Title, p.Content, p.AuthorID, p.CommunityID)
+	_, err = db.ExecContext(ctx, sqlStr, p.ID, p.Title, p.Content, p.AuthorID, p.CommunityID)
 	return
 }
 
-// GetPostById 根据id查询单个帖子数据
-func GetPostById(pid int64) (post *models.Post, err error) {
+// GetPostById 根据id查询单个帖子数据，已删除的帖子视为不存在
+func GetPostById(ctx context.Context, pid int64) (post *models.Post, err error) {
+	ctx, span := tracing.Start(ctx, "mysql.GetPostById")
+	defer func() { tracing.End(span, err) }()
 	post = new(models.Post)
 	sqlStr := `select
-	post_id, title, content, author_id, community_id, create_time
+	post_id, title, content, author_id, community_id, status, create_time, update_time
 	from post
-	where post_id = ?`
-	err = db.Get(post, sqlStr, pid)
+	where post_id = ? and status != ?`
+	err = db.GetContext(ctx, post, sqlStr, pid, models.PostStatusDeleted)
+	if err == sql.ErrNoRows {
+		err = ErrorInvalidID
+	}
 	return
 }
 
 // GetPostList 查询帖子列表函数 (限制每页贴子数)
-func GetPostList(page, size int64) (posts []*models.Post, err error) {
+func GetPostList(ctx context.Context, page, size int64) (posts []*models.Post, err error) {
+	ctx, span := tracing.Start(ctx, "mysql.GetPostList")
+	defer func() { tracing.End(span, err) }()
 	sqlStr := `select 
-	post_id, title, content, author_id, community_id, create_time
+	post_id, title, content, author_id, community_id, status, create_time, update_time
 	from post
+	where status != ?
 	ORDER BY create_time
 	DESC
 	limit ?,?
 	`
 	posts = make([]*models.Post, 0, 2)
-	err = db.Select(&posts, sqlStr, (page-1)*size, size)
+	err = db.SelectContext(ctx, &posts, sqlStr, models.PostStatusDeleted, (page-1)*size, size)
 	return
 }
 
 // GetPostListByIDs 根据给定的id列表查询帖子数据
-func GetPostListByIDs(ids []string) (postList []*models.Post, err error) {
-	sqlStr := `select post_id, title, content, author_id, community_id, create_time
+func GetPostListByIDs(ctx context.Context, ids []string) (postList []*models.Post, err error) {
+	ctx, span := tracing.Start(ctx, "mysql.GetPostListByIDs")
+	defer func() { tracing.End(span, err) }()
+	sqlStr := `select post_id, title, content, author_id, community_id, status, create_time, update_time
 	from post
-	where post_id in (?)
+	where post_id in (?) and status != ?
 	order by FIND_IN_SET(post_id, ?)`
 
 	// 使用sqlx.In帮我们拼接语句和参数, 注意传入的参数是[]interface{} [1 2 3] => [1,2,3]
-	query, args, err := sqlx.In(sqlStr, ids, strings.Join(ids, ","))
+	query, args, err := sqlx.In(sqlStr, ids, models.PostStatusDeleted, strings.Join(ids, ","))
 	if err != nil {
 		return nil, err
 	}
 	// sqlx.In 返回带 `?` bindvar的查询语句, 我们使用Rebind()重新绑定
 	query = db.Rebind(query)
-	err = db.Select(&postList, query, args...)
+	err = db.SelectContext(ctx, &postList, query, args...)
+	return
+}
+
+// UpdatePost 修改帖子标题和内容，修改前的版本保存到post_revision表
+func UpdatePost(ctx context.Context, p *models.Post, editorID int64) (err error) {
+	ctx, span := tracing.Start(ctx, "mysql.UpdatePost")
+	defer func() { tracing.End(span, err) }()
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+		err = tx.Commit()
+	}()
+
+	old := new(models.Post)
+	sqlStr := `select title, content from post where post_id = ? and status != ? for update`
+	if err = tx.GetContext(ctx, old, sqlStr, p.ID, models.PostStatusDeleted); err != nil {
+		if err == sql.ErrNoRows {
+			err = ErrorInvalidID
+		}
+		return
+	}
+	sqlStr = `insert into post_revision(post_id, editor_id, title, content) values (?, ?, ?, ?)`
+	if _, err = tx.ExecContext(ctx, sqlStr, p.ID, editorID, old.Title, old.Content); err != nil {
+		return
+	}
+	sqlStr = `update post set title = ?, content = ? where post_id = ?`
+	_, err = tx.ExecContext(ctx, sqlStr, p.Title, p.Content, p.ID)
+	return
+}
+
+// DeletePost 软删除帖子
+func DeletePost(ctx context.Context, pid int64) (err error) {
+	ctx, span := tracing.Start(ctx, "mysql.DeletePost")
+	defer func() { tracing.End(span, err) }()
+	sqlStr := `update post set status = ? where post_id = ? and status != ?`
+	ret, err := db.ExecContext(ctx, sqlStr, models.PostStatusDeleted, pid, models.PostStatusDeleted)
+	if err != nil {
+		return
+	}
+	return checkAffected(ret)
+}
+
+// SetPostStatus 修改未删除帖子的状态(锁定/解锁)
+func SetPostStatus(ctx context.Context, pid int64, status int32) (err error) {
+	ctx, span := tracing.Start(ctx, "mysql.SetPostStatus")
+	defer func() { tracing.End(span, err) }()
+	sqlStr := `update post set status = ? where post_id = ? and status != ?`
+	ret, err := db.ExecContext(ctx, sqlStr, status, pid, models.PostStatusDeleted)
+	if err != nil {
+		return
+	}
+	return checkAffected(ret)
+}
+
+// GetPostRevisions 查询帖子的历史版本，按修改时间从新到旧排序
+func GetPostRevisions(ctx context.Context, pid int64) (revisions []*models.PostRevision, err error) {
+	ctx, span := tracing.Start(ctx, "mysql.GetPostRevisions")
+	defer func() { tracing.End(span, err) }()
+	sqlStr := `select post_id, editor_id, title, content, create_time
+	from post_revision
+	where post_id = ?
+	order by id desc`
+	revisions = make([]*models.PostRevision, 0)
+	err = db.SelectContext(ctx, &revisions, sqlStr, pid)
 	return
 }
@@ -0,0 +1,33 @@
+package mysql
+
+import (
+	"bluebell/pkg/tracing"
+	"context"
+)
+
+// Subscribe 订阅社区，重复订阅不报错
+func Subscribe(ctx context.Context, userID, communityID int64) (err error) {
+	ctx, span := tracing.Start(ctx, "mysql.Subscribe")
+	defer func() { tracing.End(span, err) }()
+	sqlStr := `insert ignore into community_subscription(user_id, community_id) values (?, ?)`
+	_, err = db.ExecContext(ctx, sqlStr, userID, communityID)
+	return
+}
+
+// Unsubscribe 取消订阅社区
+func Unsubscribe(ctx context.Context, userID, communityID int64) (err error) {
+	ctx, span := tracing.Start(ctx, "mysql.Unsubscribe")
+	defer func() { tracing.End(span, err) }()
+	sqlStr := `delete from community_subscription where user_id = ? and community_id = ?`
+	_, err = db.ExecContext(ctx, sqlStr, userID, communityID)
+	return
+}
+
+// GetSubscribedCommunityIDs 查询用户订阅的社区id
+func GetSubscribedCommunityIDs(ctx context.Context, userID int64) (ids []int64, err error) {
+	ctx, span := tracing.Start(ctx, "mysql.GetSubscribedCommunityIDs")
+	defer func() { tracing.End(span, err) }()
+	sqlStr := `select community_id from community_subscription where user_id = ?`
+	err = db.SelectContext(ctx, &ids, sqlStr, userID)
+	return
+}
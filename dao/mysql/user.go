@@ -1,21 +1,27 @@
 package mysql
 
 import (
+	"bluebell/logger"
 	"bluebell/models"
-	"crypto/md5"
+	"bluebell/pkg/password"
+	"bluebell/pkg/tracing"
+	"context"
 	"database/sql"
-	"encoding/hex"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
 )
 
 // 把每一步数据库操作封装成函数
 // 待logic层根据业务需求调用
-const secret = "chenchenchen.com"
 
 // CheckUserExist 检查指定用户名的用户是否存在
-func CheckUserExist(username string) (err error) {
+func CheckUserExist(ctx context.Context, username string) (err error) {
+	ctx, span := tracing.Start(ctx, "mysql.CheckUserExist")
+	defer func() { tracing.End(span, err) }()
 	sqlStr := `select count(user_id) from user where username = ?`
 	var count int64
-	if err := db.Get(&count, sqlStr, username); err != nil {
+	if err := db.GetContext(ctx, &count, sqlStr, username); err != nil {
 		return err
 	}
 	if count > 0 {
@@ -25,44 +31,112 @@ func CheckUserExist(username string) (err error) {
 }
 
 // InsertUser 想数据库中插入一条新的用户记录
-func InsertUser(user *models.User) (err error) {
-	// 对密码进行解密
-	user.Password = encryptPassword(user.Password)
+func InsertUser(ctx context.Context, user *models.User) (err error) {
+	ctx, span := tracing.Start(ctx, "mysql.InsertUser")
+	defer func() { tracing.End(span, err) }()
+	// 对密码进行加密
+	user.Password, err = password.Hash(user.Password)
+	if err != nil {
+		return
+	}
 	// 执行sql执行语句
 	sqlStr := `insert into user(user_id,username,password) values(?,?,?)`
-	_, err = db.Exec(sqlStr, user.UserID, user.Username, user.Password)
+	_, err = db.ExecContext(ctx, sqlStr, user.UserID, user.Username, user.Password)
 	return
 }
 
-// encryptPassword 密码加密
-func encryptPassword(oPassword string) string {
-	h := md5.New()
-	h.Write([]byte(secret))
-	return hex.EncodeToString(h.Sum([]byte(oPassword)))
-}
-func Login(user *models.User) (err error) {
+// Login 校验用户名和密码，使用旧算法保存的密码在登录成功后升级为新算法
+// 用户名不存在时同样返回ErrorInvalidPassword
+func Login(ctx context.Context, user *models.User) (err error) {
+	ctx, span := tracing.Start(ctx, "mysql.Login")
+	defer func() { tracing.End(span, err) }()
 	oPassword := user.Password // 用户登录的密码
-	sqlStr := `select user_id, username, password from user where username=?`
-	err = db.Get(user, sqlStr, user.Username)
+	sqlStr := `select user_id, username, password, role, status from user where username=?`
+	err = db.GetContext(ctx, user, sqlStr, user.Username)
 	if err == sql.ErrNoRows {
-		return ErrorUserNotExist
+		// 同样校验一次密码，避免通过响应时间判断用户名是否存在
+		password.DummyVerify(oPassword)
+		return ErrorInvalidPassword
 	}
 	if err != nil {
 		// 查询数据库失败
 		return err
 	}
 	// 判断密码是否正确
-	password := encryptPassword(oPassword)
-	if password != user.Password {
+	ok, needRehash, err := password.Verify(oPassword, user.Password)
+	if err != nil {
+		return err
+	}
+	if !ok {
 		return ErrorInvalidPassword
 	}
+	if needRehash {
+		// 升级失败不影响本次登录，下次登录时会再次尝试
+		if err := updatePassword(ctx, user.UserID, oPassword); err != nil {
+			logger.FromContext(ctx).Error("upgrade password hash failed", zap.Int64("user_id", user.UserID), zap.Error(err))
+		}
+	}
 	return
 }
 
+// updatePassword 使用默认算法重新计算并保存用户密码
+func updatePassword(ctx context.Context, userID int64, oPassword string) (err error) {
+	encoded, err := password.Hash(oPassword)
+	if err != nil {
+		return err
+	}
+	sqlStr := `update user set password = ? where user_id = ?`
+	_, err = db.ExecContext(ctx, sqlStr, encoded, userID)
+	return err
+}
+
 // GetUserById 根据id获取用户信息
-func GetUserById(uid int64) (user *models.User, err error) {
+func GetUserById(ctx context.Context, uid int64) (user *models.User, err error) {
+	ctx, span := tracing.Start(ctx, "mysql.GetUserById")
+	defer func() { tracing.End(span, err) }()
 	user = new(models.User)
-	sqlStr := `select user_id,username from user where user_id = ?`
-	err = db.Get(user, sqlStr, uid)
+	sqlStr := `select user_id,username,role,status from user where user_id = ?`
+	err = db.GetContext(ctx, user, sqlStr, uid)
+	if err == sql.ErrNoRows {
+		err = ErrorInvalidID
+	}
+	return
+}
+
+// SetUserStatus 修改用户状态(封禁/解封)
+func SetUserStatus(ctx context.Context, uid int64, status int8) (err error) {
+	ctx, span := tracing.Start(ctx, "mysql.SetUserStatus")
+	defer func() { tracing.End(span, err) }()
+	sqlStr := `update user set status = ? where user_id = ?`
+	ret, err := db.ExecContext(ctx, sqlStr, status, uid)
+	if err != nil {
+		return
+	}
+	return checkAffected(ret)
+}
+
+// SetUserRole 修改用户角色
+func SetUserRole(ctx context.Context, uid int64, role models.Role) (err error) {
+	ctx, span := tracing.Start(ctx, "mysql.SetUserRole")
+	defer func() { tracing.End(span, err) }()
+	sqlStr := `update user set role = ? where user_id = ?`
+	_, err = db.ExecContext(ctx, sqlStr, role, uid)
+	return
+}
+
+// GetUsersByIDs 根据id列表批量查询用户信息
+func GetUsersByIDs(ctx context.Context, ids []int64) (users []*models.User, err error) {
+	ctx, span := tracing.Start(ctx, "mysql.GetUsersByIDs")
+	defer func() { tracing.End(span, err) }()
+	if len(ids) == 0 {
+		return
+	}
+	sqlStr := `select user_id,username,role,status from user where user_id in (?)`
+	query, args, err := sqlx.In(sqlStr, ids)
+	if err != nil {
+		return nil, err
+	}
+	query = db.Rebind(query)
+	err = db.SelectContext(ctx, &users, query, args...)
 	return
 }
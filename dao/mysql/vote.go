@@ -0,0 +1,74 @@
+package mysql
+
+import (
+	"bluebell/models"
+	"bluebell/pkg/tracing"
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// 每条insert语句最多插入的投票记录数
+const voteRecordBatchSize = 500
+
+// GetUnarchivedPostIDs 查询发布时间早于before且投票数据尚未归档的帖子id，不包括已删除的帖子
+// 只根据post_vote判断，已归档的帖子不会再被查出，即使redis中还留有投票记录
+func GetUnarchivedPostIDs(ctx context.Context, before time.Time, limit int) (ids []int64, err error) {
+	ctx, span := tracing.Start(ctx, "mysql.GetUnarchivedPostIDs")
+	defer func() { tracing.End(span, err) }()
+	sqlStr := `select p.post_id
+	from post p
+	left join post_vote v on p.post_id = v.post_id
+	where p.create_time < ? and p.status != ? and v.post_id is null
+	order by p.create_time
+	limit ?`
+	err = db.SelectContext(ctx, &ids, sqlStr, before, models.PostStatusDeleted, limit)
+	return
+}
+
+// ArchivePostVote 在一个事务中保存帖子的投票统计及投票记录
+// 使用insert ignore，重复归档同一个帖子不会覆盖已有数据
+func ArchivePostVote(ctx context.Context, vote *models.PostVote, records []*models.PostVoteRecord) (err error) {
+	ctx, span := tracing.Start(ctx, "mysql.ArchivePostVote")
+	defer func() { tracing.End(span, err) }()
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+		err = tx.Commit()
+	}()
+
+	sqlStr := `insert ignore into post_vote(post_id, up_count, down_count) values (?, ?, ?)`
+	if _, err = tx.ExecContext(ctx, sqlStr, vote.PostID, vote.UpCount, vote.DownCount); err != nil {
+		return
+	}
+	sqlStr = `insert ignore into post_vote_record(post_id, user_id, direction)
+	values (:post_id, :user_id, :direction)`
+	for start := 0; start < len(records); start += voteRecordBatchSize {
+		end := min(start+voteRecordBatchSize, len(records))
+		if _, err = tx.NamedExecContext(ctx, sqlStr, records[start:end]); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// GetPostVotesByIDs 根据帖子id查询已归档的投票统计
+func GetPostVotesByIDs(ctx context.Context, ids []string) (votes []*models.PostVote, err error) {
+	ctx, span := tracing.Start(ctx, "mysql.GetPostVotesByIDs")
+	defer func() { tracing.End(span, err) }()
+	sqlStr := `select post_id, up_count, down_count from post_vote where post_id in (?)`
+	query, args, err := sqlx.In(sqlStr, ids)
+	if err != nil {
+		return nil, err
+	}
+	query = db.Rebind(query)
+	err = db.SelectContext(ctx, &votes, query, args...)
+	return
+}
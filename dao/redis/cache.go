@@ -0,0 +1,136 @@
+package redis
+
+import (
+	"bluebell/pkg/tracing"
+	"context"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+// 读取缓存时同时读取版本号，删除缓存时版本号加1
+// 写入缓存时版本号与读取时不同，说明回源期间数据被修改过，不写入读到的旧数据
+
+// cacheVersionTTL 版本号的有效期，远大于一次回源的耗时即可
+const cacheVersionTTL = 24 * time.Hour
+
+// GetCaches 批量读取缓存，返回其中存在的key，以及每个key当前的版本号
+func GetCaches(ctx context.Context, keys []string) (_ map[string][]byte, versions map[string]string, err error) {
+	_, span := tracing.Start(ctx, "redis.GetCaches")
+	defer func() { tracing.End(span, err) }()
+	if len(keys) == 0 {
+		return nil, nil, nil
+	}
+	redisKeys := make([]string, 0, 2*len(keys))
+	for _, key := range keys {
+		redisKeys = append(redisKeys, getRedisKey(keyCachePF+key))
+	}
+	for _, key := range keys {
+		redisKeys = append(redisKeys, getRedisKey(keyCacheVersionPF+key))
+	}
+	vals, err := client().MGet(redisKeys...).Result()
+	if err != nil {
+		return nil, nil, err
+	}
+	found := make(map[string][]byte, len(keys))
+	versions = make(map[string]string, len(keys))
+	for idx, key := range keys {
+		if s, ok := vals[idx].(string); ok {
+			found[key] = []byte(s)
+		}
+		versions[key] = "0"
+		if s, ok := vals[len(keys)+idx].(string); ok {
+			versions[key] = s
+		}
+	}
+	return found, versions, nil
+}
+
+// setCachesScript 只写入版本号没有变化的缓存
+// KEYS: 缓存key1, 版本号key1, 缓存key2, 版本号key2...
+// ARGV: 过期时间(毫秒), 值1, 读取时的版本号1, 值2, 读取时的版本号2...
+// 返回: 写入的key的序号，从1开始
+var setCachesScript = redis.NewScript(`
+local stored = {}
+for i = 1, #KEYS / 2 do
+	local version = redis.call('GET', KEYS[2 * i]) or '0'
+	if version == ARGV[2 * i + 1] then
+		redis.call('SET', KEYS[2 * i - 1], ARGV[2 * i], 'PX', ARGV[1])
+		stored[#stored + 1] = i
+	end
+end
+return stored
+`)
+
+// SetCaches 批量写入缓存，versions是读取缓存时返回的版本号
+// 版本号已经变化的key不会写入，返回写入成功的key
+func SetCaches(ctx context.Context, items map[string][]byte, versions map[string]string, expiration time.Duration) (stored []string, err error) {
+	_, span := tracing.Start(ctx, "redis.SetCaches")
+	defer func() { tracing.End(span, err) }()
+	if len(items) == 0 {
+		return nil, nil
+	}
+	keys := make([]string, 0, len(items))
+	redisKeys := make([]string, 0, 2*len(items))
+	args := make([]interface{}, 0, 2*len(items)+1)
+	args = append(args, expiration.Milliseconds())
+	for key, value := range items {
+		version, ok := versions[key]
+		if !ok {
+			version = "0"
+		}
+		keys = append(keys, key)
+		redisKeys = append(redisKeys, getRedisKey(keyCachePF+key), getRedisKey(keyCacheVersionPF+key))
+		args = append(args, value, version)
+	}
+	res, err := setCachesScript.Run(client(), redisKeys, args...).Result()
+	if err != nil {
+		return nil, err
+	}
+	for _, idx := range res.([]interface{}) {
+		stored = append(stored, keys[idx.(int64)-1])
+	}
+	return stored, nil
+}
+
+// DelCaches 删除缓存并增加版本号，通知所有实例删除各自的本地缓存
+func DelCaches(ctx context.Context, keys ...string) (err error) {
+	_, span := tracing.Start(ctx, "redis.DelCaches")
+	defer func() { tracing.End(span, err) }()
+	redisKeys := make([]string, 0, len(keys))
+	for _, key := range keys {
+		redisKeys = append(redisKeys, getRedisKey(keyCachePF+key))
+	}
+	pipeline := client().Pipeline()
+	pipeline.Del(redisKeys...)
+	for _, key := range keys {
+		versionKey := getRedisKey(keyCacheVersionPF + key)
+		pipeline.Incr(versionKey)
+		pipeline.Expire(versionKey, cacheVersionTTL)
+		pipeline.Publish(getRedisKey(keyCacheInvalidateChannel), key)
+	}
+	_, err = pipeline.Exec()
+	return err
+}
+
+// WatchCacheInvalidation 订阅缓存失效通知，直到ctx被取消
+func WatchCacheInvalidation(ctx context.Context, fn func(key string)) error {
+	pubsub := client().Subscribe(getRedisKey(keyCacheInvalidateChannel))
+	defer pubsub.Close()
+	// 等待订阅成功
+	if _, err := pubsub.Receive(); err != nil {
+		return err
+	}
+	ch := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg, ok := <-ch:
+			if !ok {
+				return nil
+			}
+			fn(msg.Payload)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package redis
+
+import (
+	"bluebell/pkg/tracing"
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+// CreateComment 记录评论时间及初始分数
+func CreateComment(ctx context.Context, commentID, postID int64) (err error) {
+	_, span := tracing.Start(ctx, "redis.CreateComment")
+	defer func() { tracing.End(span, err) }()
+	pid := strconv.FormatInt(postID, 10)
+	now := float64(time.Now().Unix())
+	pipeline := client().TxPipeline()
+	pipeline.ZAdd(getRedisKey(keyCommentTimeZSetPF+pid), redis.Z{
+		Score:  now,
+		Member: commentID,
+	})
+	pipeline.ZAdd(getRedisKey(keyCommentScoreZSetPF+pid), redis.Z{
+		Score:  now,
+		Member: commentID,
+	})
+	_, err = pipeline.Exec()
+	return err
+}
+
+// VoteForComment 为评论投票，规则与帖子投票相同
+func VoteForComment(ctx context.Context, userID, postID, commentID string, value float64) (err error) {
+	_, span := tracing.Start(ctx, "redis.VoteForComment")
+	defer func() { tracing.End(span, err) }()
+	keys := []string{
+		getRedisKey(keyCommentTimeZSetPF + postID),
+		getRedisKey(keyCommentScoreZSetPF + postID),
+		getRedisKey(KeyCommentVotedZSetPF + commentID),
+	}
+	return runVoteScript(keys, commentID, userID, value)
+}
+
+// GetCommentScores 查询帖子下指定评论的分数，没有分数的评论不在结果中
+func GetCommentScores(ctx context.Context, postID int64, ids []int64) (_ map[int64]float64, err error) {
+	_, span := tracing.Start(ctx, "redis.GetCommentScores")
+	defer func() { tracing.End(span, err) }()
+	scores := make(map[int64]float64, len(ids))
+	if len(ids) == 0 {
+		return scores, nil
+	}
+	key := getRedisKey(keyCommentScoreZSetPF + strconv.FormatInt(postID, 10))
+	pipeline := client().Pipeline()
+	cmds := make([]*redis.FloatCmd, 0, len(ids))
+	for _, id := range ids {
+		cmds = append(cmds, pipeline.ZScore(key, strconv.FormatInt(id, 10)))
+	}
+	// 不存在的member返回redis.Nil，逐条判断错误
+	_, _ = pipeline.Exec()
+	for idx, cmd := range cmds {
+		switch cmd.Err() {
+		case nil:
+			scores[ids[idx]] = cmd.Val()
+		case redis.Nil:
+		default:
+			return nil, cmd.Err()
+		}
+	}
+	return scores, nil
+}
+
+// GetCommentVoteData 根据ids查询每条评论的赞成票数
+func GetCommentVoteData(ctx context.Context, ids []int64) (data []int64, err error) {
+	_, span := tracing.Start(ctx, "redis.GetCommentVoteData")
+	defer func() { tracing.End(span, err) }()
+	pipeline := client().Pipeline()
+	cmds := make([]*redis.IntCmd, 0, len(ids))
+	for _, id := range ids {
+		key := getRedisKey(KeyCommentVotedZSetPF + strconv.FormatInt(id, 10))
+		cmds = append(cmds, pipeline.ZCount(key, "1", "1"))
+	}
+	if _, err = pipeline.Exec(); err != nil {
+		return nil, err
+	}
+	data = make([]int64, 0, len(cmds))
+	for _, cmd := range cmds {
+		data = append(data, cmd.Val())
+	}
+	return
+}
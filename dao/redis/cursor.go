@@ -0,0 +1,91 @@
+package redis
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/go-redis/redis"
+)
+
+var ErrInvalidCursor = errors.New("无效的cursor")
+
+// 游标分页
+// 游标是上一页最后一个元素的分数和id，对客户端来说是不透明的字符串
+// 下一页从该元素之后开始，用 ZREVRANGEBYSCORE 按分数定位，不受新增帖子的影响，也不需要跳过前面的数据
+
+// encodeCursor 把分数和id编码成游标
+func encodeCursor(score float64, member string) string {
+	raw := strconv.FormatFloat(score, 'f', -1, 64) + ":" + member
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeCursor 解析游标中的分数和id
+func decodeCursor(cursor string) (score float64, member string, err error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, "", ErrInvalidCursor
+	}
+	parts := strings.SplitN(string(raw), ":", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return 0, "", ErrInvalidCursor
+	}
+	score, err = strconv.ParseFloat(parts[0], 64)
+	if err != nil {
+		return 0, "", ErrInvalidCursor
+	}
+	return score, parts[1], nil
+}
+
+// getIDsFromKeyByCursor 按分数从大到小查询游标之后的size个元素，并返回下一页的游标
+// 没有更多数据时返回的游标为空
+func getIDsFromKeyByCursor(key, cursor string, size int64) (ids []string, next string, err error) {
+	if size <= 0 {
+		return nil, "", nil
+	}
+	max := "+inf"
+	var (
+		lastScore  float64
+		lastMember string
+	)
+	if cursor != "" {
+		if lastScore, lastMember, err = decodeCursor(cursor); err != nil {
+			return
+		}
+		max = strconv.FormatFloat(lastScore, 'f', -1, 64)
+	}
+
+	ids = make([]string, 0, size)
+	var last redis.Z
+	// 多查一条用来判断是否还有下一页
+	// 分数相同的元素按member倒序排列，跳过其中已经返回过的元素
+	for offset := int64(0); int64(len(ids)) <= size; {
+		zs, err := client().ZRevRangeByScoreWithScores(key, redis.ZRangeBy{
+			Max:    max,
+			Min:    "-inf",
+			Offset: offset,
+			Count:  size + 1,
+		}).Result()
+		if err != nil {
+			return nil, "", err
+		}
+		for _, z := range zs {
+			member := z.Member.(string)
+			if cursor != "" && z.Score == lastScore && member >= lastMember {
+				continue
+			}
+			if int64(len(ids)) == size {
+				// 还有下一页，用本页最后一个元素生成游标
+				return ids, encodeCursor(last.Score, last.Member.(string)), nil
+			}
+			ids = append(ids, member)
+			last = z
+		}
+		if int64(len(zs)) < size+1 {
+			break
+		}
+		offset += int64(len(zs))
+	}
+	return ids, "", nil
+}
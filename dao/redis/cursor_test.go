@@ -0,0 +1,61 @@
+package redis
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/go-redis/redis"
+)
+
+// 分数相同的元素跨页、翻页期间插入新元素时，游标分页既不重复也不遗漏
+func TestGetIDsFromKeyByCursor(t *testing.T) {
+	setupMiniRedis(t)
+	key := getRedisKey("cursor:test")
+	for i := 0; i < 25; i++ {
+		// 每5个元素分数相同
+		client().ZAdd(key, redis.Z{Score: float64(100 - i/5), Member: strconv.Itoa(100 + i)})
+	}
+
+	seen := make(map[string]bool)
+	cursor := ""
+	for page := 0; ; page++ {
+		ids, next, err := getIDsFromKeyByCursor(key, cursor, 7)
+		if err != nil {
+			t.Fatalf("getIDsFromKeyByCursor failed, err:%v", err)
+		}
+		for _, id := range ids {
+			if seen[id] {
+				t.Fatalf("id %s returned twice", id)
+			}
+			seen[id] = true
+		}
+		if page == 0 {
+			// 新发布的帖子分数更高，不影响后面的页
+			client().ZAdd(key, redis.Z{Score: 200, Member: "999"})
+		}
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+	if len(seen) != 25 {
+		t.Fatalf("got %d ids, want 25", len(seen))
+	}
+}
+
+func TestDecodeInvalidCursor(t *testing.T) {
+	setupMiniRedis(t)
+	if _, _, err := getIDsFromKeyByCursor(getRedisKey("cursor:test"), "!!", 10); err != ErrInvalidCursor {
+		t.Fatalf("err:%v, want %v", err, ErrInvalidCursor)
+	}
+}
+
+func TestGetIDsFromKeyByCursorZeroSize(t *testing.T) {
+	setupMiniRedis(t)
+	key := getRedisKey("cursor:test")
+	client().ZAdd(key, redis.Z{Score: 1, Member: "1"})
+	ids, next, err := getIDsFromKeyByCursor(key, "", 0)
+	if err != nil || len(ids) != 0 || next != "" {
+		t.Fatalf("got ids:%v next:%q err:%v, want empty", ids, next, err)
+	}
+}
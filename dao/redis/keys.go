@@ -4,14 +4,27 @@ package redis
 
 // redis key注意使用命名空间的方式,方便查询和拆分
 const (
-	Prefix             = "bluebell:"   // 项目key前缀
-	keyPostTimeZSet    = "post:time"   // zset;帖子及发帖时间
-	keyPostScoreZSet   = "post:score"  // zset;帖子及投票的分数
-	KeyPostVotedZSetPF = "post:voted:" // zset;记录用户及投票类型;参数是post id
-	keyCommunitySetPF  = "community:"  // set;保存每个分区下帖子的id
+	Prefix                    = "bluebell:"        // 项目key前缀
+	keyPostTimeZSet           = "post:time"        // zset;帖子及发帖时间
+	keyPostScoreZSet          = "post:score"       // zset;帖子及投票的分数
+	KeyPostVotedZSetPF        = "post:voted:"      // zset;记录用户及投票类型;参数是post id
+	keyPostArchivingSet       = "post:archiving"   // set;正在归档投票数据、redis记录还未删除的帖子id
+	keyCommunitySetPF         = "community:"       // set;保存每个分区下帖子的id
+	keyCommentTimeZSetPF      = "comment:time:"    // zset;帖子下的评论及评论时间;参数是post id
+	keyCommentScoreZSetPF     = "comment:score:"   // zset;帖子下的评论及投票的分数;参数是post id
+	KeyCommentVotedZSetPF     = "comment:voted:"   // zset;记录用户及投票类型;参数是comment id
+	keyFeedZSetPF             = "feed:"            // zset;用户订阅的社区的帖子,按时间或分数排序的缓存;参数是排序方式和user id
+	keyCachePF                = "cache:"           // string;mysql数据的缓存;参数是数据类型和id
+	keyCacheVersionPF         = "cache:version:"   // string;缓存的版本号，删除缓存时加1;参数是数据类型和id
+	keyCacheInvalidateChannel = "cache:invalidate" // channel;通知各实例删除本地缓存
+	keyRateLimitPF            = "ratelimit:"       // string;GCRA限流的理论到达时间;参数是规则和用户id或ip
+	keyLoginFailPF            = "login:fail:"      // string;连续登录失败的次数;参数是user/ip及用户名或ip
+	keyLoginLockPF            = "login:lock:"      // string;禁止登录直到key过期;参数是user/ip及用户名或ip
+	keyTokenVersionPF         = "token:version:"   // string;用户当前的token版本号;参数是user id
+	keyRefreshTokenPF         = "token:refresh:"   // string;尚未使用的refresh token;参数是token id
 )
 
 // 给redis key加上前缀
 func getRedisKey(key string) string {
 	return Prefix + key
-}
\ No newline at end of file
+}
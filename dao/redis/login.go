@@ -0,0 +1,66 @@
+package redis
+
+import (
+	"bluebell/pkg/tracing"
+	"context"
+	"time"
+)
+
+// 登录失败的计数对象
+const (
+	LoginSubjectUser = "user" // 按用户名计数
+	LoginSubjectIP   = "ip"   // 按客户端ip计数
+)
+
+// RecordLoginFailure 记录一次登录失败，返回该对象在window内累计的失败次数
+// 每次失败都会重新计时，最后一次失败window之后计数清零
+func RecordLoginFailure(ctx context.Context, subject, id string, window time.Duration) (_ int64, err error) {
+	_, span := tracing.Start(ctx, "redis.RecordLoginFailure")
+	defer func() { tracing.End(span, err) }()
+	key := getRedisKey(keyLoginFailPF + subject + ":" + id)
+	pipeline := client().TxPipeline()
+	incr := pipeline.Incr(key)
+	pipeline.Expire(key, window)
+	if _, err := pipeline.Exec(); err != nil {
+		return 0, err
+	}
+	return incr.Val(), nil
+}
+
+// ClearLoginFailure 登录成功后清除失败计数和锁定
+func ClearLoginFailure(ctx context.Context, subject, id string) (err error) {
+	_, span := tracing.Start(ctx, "redis.ClearLoginFailure")
+	defer func() { tracing.End(span, err) }()
+	return client().Del(
+		getRedisKey(keyLoginFailPF+subject+":"+id),
+		getRedisKey(keyLoginLockPF+subject+":"+id),
+	).Err()
+}
+
+// LockLogin 在d时间内禁止该对象登录
+func LockLogin(ctx context.Context, subject, id string, d time.Duration) (err error) {
+	_, span := tracing.Start(ctx, "redis.LockLogin")
+	defer func() { tracing.End(span, err) }()
+	return client().Set(getRedisKey(keyLoginLockPF+subject+":"+id), 1, d).Err()
+}
+
+// GetLoginLock 查询用户名和ip的锁定剩余时间，取较长的一个，未锁定时返回0
+func GetLoginLock(ctx context.Context, username, ip string) (_ time.Duration, err error) {
+	_, span := tracing.Start(ctx, "redis.GetLoginLock")
+	defer func() { tracing.End(span, err) }()
+	pipeline := client().Pipeline()
+	userTTL := pipeline.PTTL(getRedisKey(keyLoginLockPF + LoginSubjectUser + ":" + username))
+	ipTTL := pipeline.PTTL(getRedisKey(keyLoginLockPF + LoginSubjectIP + ":" + ip))
+	if _, err := pipeline.Exec(); err != nil {
+		return 0, err
+	}
+	// key不存在时PTTL返回负数
+	d := userTTL.Val()
+	if ipTTL.Val() > d {
+		d = ipTTL.Val()
+	}
+	if d < 0 {
+		d = 0
+	}
+	return d, nil
+}
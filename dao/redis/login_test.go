@@ -0,0 +1,47 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLoginFailureAndLock(t *testing.T) {
+	mr := setupMiniRedis(t)
+	for i := int64(1); i <= 3; i++ {
+		n, err := RecordLoginFailure(context.Background(), LoginSubjectUser, "alice", time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != i {
+			t.Fatalf("fails = %d, want %d", n, i)
+		}
+	}
+	if d, err := GetLoginLock(context.Background(), "alice", "1.2.3.4"); err != nil || d != 0 {
+		t.Fatalf("lock = %v, %v, want 0", d, err)
+	}
+
+	if err := LockLogin(context.Background(), LoginSubjectIP, "1.2.3.4", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	d, err := GetLoginLock(context.Background(), "bob", "1.2.3.4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d <= 0 || d > time.Minute {
+		t.Fatalf("lock = %v, want (0, 1m]", d)
+	}
+
+	// 锁定到期后自动解除
+	mr.FastForward(time.Minute)
+	if d, _ := GetLoginLock(context.Background(), "bob", "1.2.3.4"); d != 0 {
+		t.Fatalf("lock = %v after expire", d)
+	}
+
+	if err := ClearLoginFailure(context.Background(), LoginSubjectUser, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := RecordLoginFailure(context.Background(), LoginSubjectUser, "alice", time.Hour); n != 1 {
+		t.Fatalf("fails after clear = %d, want 1", n)
+	}
+}
@@ -2,42 +2,54 @@ package redis
 
 import (
 	"bluebell/models"
+	"bluebell/pkg/tracing"
+	"context"
 	"strconv"
 	"time"
 
 	"github.com/go-redis/redis"
 )
 
-func getIDsFromKey(key string, page, size int64) ([]string, error) {
+func getIDsFromKey(key string, page, size int64) (_ []string, err error) {
 	start := (page - 1) * size
 	end := start + size - 1
 	// 3. ZREVRANGE 按分数从大到小的顺序查询指定数量的元素
-	return client.ZRevRange(key, start, end).Result()
+	return client().ZRevRange(key, start, end).Result()
 }
 
-func GetPostIDsInOrder(p *models.ParamPostList) ([]string, error) {
+// getIDs 根据请求参数选择页码分页或游标分页，页码分页时返回的游标为空
+func getIDs(key string, p *models.ParamPostList) (ids []string, next string, err error) {
+	if p.UseCursor {
+		return getIDsFromKeyByCursor(key, p.Cursor, p.Size)
+	}
+	ids, err = getIDsFromKey(key, p.Page, p.Size)
+	return
+}
+
+func GetPostIDsInOrder(ctx context.Context, p *models.ParamPostList) (_ []string, _ string, err error) {
+	_, span := tracing.Start(ctx, "redis.GetPostIDsInOrder")
+	defer func() { tracing.End(span, err) }()
 	// 从redis获取id
 	// 1.根据用户请求中携带的order参数确定要查询的redis key
-	key := getRedisKey(keyPostTimeZSet)
-	if p.Order == models.OrderScore {
-		key = getRedisKey(keyPostScoreZSet)
-	}
+	key := getOrderKey(p.Order)
 	// 2. 确定拆查询的所有起始点
-	return getIDsFromKey(key, p.Page, p.Size)
+	return getIDs(key, p)
 }
 
 // GetPostVoteData 根据ids查询每篇帖子的投赞成票的数据
-func GetPostVoteData(ids []string) (data []int64, err error) {
+func GetPostVoteData(ctx context.Context, ids []string) (data []int64, err error) {
+	_, span := tracing.Start(ctx, "redis.GetPostVoteData")
+	defer func() { tracing.End(span, err) }()
 	//data = make([]int64, 0, len(ids))
 	//for _, id := range ids {
 	//	key := getRedisKey(KeyPostVotedZSetPF + id)
 	//	// 查找key中分数是1的元素的数量->统计每篇帖子的赞成票的数量
-	//	v := client.ZCount(key, "1", "1").Val()
+	//	v := client().ZCount(key, "1", "1").Val()
 	//	data = append(data, v)
 	//}
 
 	// 或者使用pipeline一次i发送多条命令，减少RTT
-	pipeline := client.Pipeline()
+	pipeline := client().Pipeline()
 	for _, id := range ids {
 		key := getRedisKey(KeyPostVotedZSetPF + id)
 		pipeline.ZCount(key, "1", "1")
@@ -54,12 +66,19 @@ func GetPostVoteData(ids []string) (data []int64, err error) {
 	return
 }
 
-// GetCommunityPostIDsInOrder 按社区查询ids
-func GetCommunityPostIDsInOrder(p *models.ParamPostList) ([]string, error) {
-	orderKey := getRedisKey(keyPostTimeZSet)
-	if p.Order == models.OrderScore {
-		orderKey = getRedisKey(keyPostScoreZSet)
+// getOrderKey 根据排序方式确定要查询的redis key
+func getOrderKey(order string) string {
+	if order == models.OrderScore {
+		return getRedisKey(keyPostScoreZSet)
 	}
+	return getRedisKey(keyPostTimeZSet)
+}
+
+// GetCommunityPostIDsInOrder 按社区查询ids
+func GetCommunityPostIDsInOrder(ctx context.Context, p *models.ParamPostList) (_ []string, _ string, err error) {
+	_, span := tracing.Start(ctx, "redis.GetCommunityPostIDsInOrder")
+	defer func() { tracing.End(span, err) }()
+	orderKey := getOrderKey(p.Order)
 
 	// 使用 zinterstore 把分区的帖子set与帖子分数的 zset 生成一个新的zset
 	// 针对新的zset 按之前的逻辑取数据
@@ -69,18 +88,78 @@ func GetCommunityPostIDsInOrder(p *models.ParamPostList) ([]string, error) {
 
 	// 利用缓存key减少zinterstore执行的次数
 	key := orderKey + strconv.Itoa(int(p.CommunityID))
-	if client.Exists(key).Val() < 1 {
+	if client().Exists(key).Val() < 1 {
 		// 不存在，需要计算
-		pipeline := client.Pipeline()
+		pipeline := client().Pipeline()
 		pipeline.ZInterStore(key, redis.ZStore{
 			Aggregate: "MAX",
 		}, cKey, orderKey) // zinterstore 计算
 		pipeline.Expire(key, 60*time.Second) // 设置超时时间
 		_, err := pipeline.Exec()
 		if err != nil {
-			return nil, err
+			return nil, "", err
 		}
 	}
 	// 存在的话就直接根据key拆线呢ids
-	return getIDsFromKey(key, p.Page, p.Size)
+	return getIDs(key, p)
+}
+
+// GetFeedPostIDsInOrder 查询用户订阅的多个社区的帖子ids
+// 先用 zunionstore 合并各社区的帖子set，再与帖子时间或分数的 zset 做 zinterstore，结果缓存60秒
+func GetFeedPostIDsInOrder(ctx context.Context, userID int64, communityIDs []int64, p *models.ParamPostList) (_ []string, _ string, err error) {
+	_, span := tracing.Start(ctx, "redis.GetFeedPostIDsInOrder")
+	defer func() { tracing.End(span, err) }()
+	orderKey := getOrderKey(p.Order)
+	key := getFeedKey(userID, p.Order)
+	if client().Exists(key).Val() < 1 {
+		cKeys := make([]string, 0, len(communityIDs))
+		for _, cid := range communityIDs {
+			cKeys = append(cKeys, getRedisKey(keyCommunitySetPF+strconv.Itoa(int(cid))))
+		}
+		unionKey := key + ":union"
+		pipeline := client().TxPipeline()
+		pipeline.ZUnionStore(unionKey, redis.ZStore{Aggregate: "MAX"}, cKeys...)
+		pipeline.ZInterStore(key, redis.ZStore{Aggregate: "MAX"}, unionKey, orderKey)
+		pipeline.Del(unionKey)
+		pipeline.Expire(key, 60*time.Second)
+		if _, err := pipeline.Exec(); err != nil {
+			return nil, "", err
+		}
+	}
+	return getIDs(key, p)
+}
+
+// ClearFeedCache 订阅的社区变化后删除用户的首页缓存
+func ClearFeedCache(ctx context.Context, userID int64) (err error) {
+	_, span := tracing.Start(ctx, "redis.ClearFeedCache")
+	defer func() { tracing.End(span, err) }()
+	return client().Del(getFeedKey(userID, models.OrderTime), getFeedKey(userID, models.OrderScore)).Err()
+}
+
+func getFeedKey(userID int64, order string) string {
+	if order != models.OrderScore {
+		order = models.OrderTime
+	}
+	return getRedisKey(keyFeedZSetPF + order + ":" + strconv.FormatInt(userID, 10))
+}
+
+// DeletePost 把帖子从排序用的zset和社区的set中移除，并删除投票记录
+func DeletePost(ctx context.Context, postID, communityID int64) (err error) {
+	_, span := tracing.Start(ctx, "redis.DeletePost")
+	defer func() { tracing.End(span, err) }()
+	pid := strconv.FormatInt(postID, 10)
+	cid := strconv.Itoa(int(communityID))
+	timeKey := getRedisKey(keyPostTimeZSet)
+	scoreKey := getRedisKey(keyPostScoreZSet)
+
+	pipeline := client().TxPipeline()
+	pipeline.ZRem(timeKey, pid)
+	pipeline.ZRem(scoreKey, pid)
+	pipeline.SRem(getRedisKey(keyCommunitySetPF+cid), pid)
+	// 社区帖子列表的缓存key
+	pipeline.ZRem(timeKey+cid, pid)
+	pipeline.ZRem(scoreKey+cid, pid)
+	pipeline.Del(getRedisKey(KeyPostVotedZSetPF + pid))
+	_, err = pipeline.Exec()
+	return err
 }
@@ -0,0 +1,63 @@
+package redis
+
+import (
+	"bluebell/pkg/tracing"
+	"context"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+// rateLimitScript GCRA限流
+// 每个key只保存一个理论到达时间(tat)，每个请求把tat推后一个发射间隔emission = period / rate
+// tat超过now + burst * emission 时拒绝请求
+// KEYS: 限流key
+// ARGV: burst, rate, period(毫秒), now(毫秒)
+// 返回: {是否允许, 剩余可用次数, 需要等待的毫秒数, 完全恢复的毫秒数}
+var rateLimitScript = redis.NewScript(`
+local burst = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local period = tonumber(ARGV[3])
+local now = tonumber(ARGV[4])
+local emission = period / rate
+
+local tat = tonumber(redis.call('GET', KEYS[1]))
+if tat == nil or tat < now then
+	tat = now
+end
+local newTat = tat + emission
+local diff = now - (newTat - burst * emission)
+if diff < 0 then
+	return {0, 0, math.ceil(-diff), math.ceil(tat - now)}
+end
+redis.call('SET', KEYS[1], newTat, 'PX', math.ceil(newTat - now))
+return {1, math.floor(diff / emission), 0, math.ceil(newTat - now)}
+`)
+
+// RateLimitResult 一次限流判断的结果
+type RateLimitResult struct {
+	Allowed    bool
+	Remaining  int64         // 当前还能立即发出的请求数
+	RetryAfter time.Duration // 被拒绝时需要等待的时间
+	ResetAfter time.Duration // 恢复到burst个可用次数需要的时间
+}
+
+// AllowRate 判断key在period内最多rate次、最多突发burst次的限制下是否允许本次请求
+// 使用调用方的时间而不是redis的时间，各实例之间的时钟需要同步
+func AllowRate(ctx context.Context, key string, rate, burst int64, period time.Duration) (_ *RateLimitResult, err error) {
+	_, span := tracing.Start(ctx, "redis.AllowRate")
+	defer func() { tracing.End(span, err) }()
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	res, err := rateLimitScript.Run(client(), []string{getRedisKey(keyRateLimitPF + key)},
+		burst, rate, period.Milliseconds(), now).Result()
+	if err != nil {
+		return nil, err
+	}
+	values := res.([]interface{})
+	return &RateLimitResult{
+		Allowed:    values[0].(int64) == 1,
+		Remaining:  values[1].(int64),
+		RetryAfter: time.Duration(values[2].(int64)) * time.Millisecond,
+		ResetAfter: time.Duration(values[3].(int64)) * time.Millisecond,
+	}, nil
+}
@@ -0,0 +1,44 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestAllowRateBurstThenReject(t *testing.T) {
+	setupMiniRedis(t)
+	// 每分钟3次，允许一次性用完
+	for i := 0; i < 3; i++ {
+		res, err := AllowRate(context.Background(), "test:ip:1", 3, 3, time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !res.Allowed {
+			t.Fatalf("request %d rejected", i)
+		}
+		if res.Remaining != int64(2-i) {
+			t.Fatalf("request %d remaining = %d, want %d", i, res.Remaining, 2-i)
+		}
+	}
+	res, err := AllowRate(context.Background(), "test:ip:1", 3, 3, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Allowed {
+		t.Fatal("4th request should be rejected")
+	}
+	// 每20秒恢复一次
+	if res.RetryAfter <= 0 || res.RetryAfter > 20*time.Second {
+		t.Fatalf("retry after = %v", res.RetryAfter)
+	}
+
+	// 不同的key互不影响
+	res, err = AllowRate(context.Background(), "test:ip:2", 3, 3, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Allowed {
+		t.Fatal("other key should be allowed")
+	}
+}
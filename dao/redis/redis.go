@@ -2,33 +2,91 @@ package redis
 
 import (
 	"bluebell/setting"
+	"context"
 	"fmt"
+	"sync/atomic"
+	"time"
 
 	"github.com/go-redis/redis"
+	"go.uber.org/zap"
 )
 
 var (
-	client *redis.Client
-	Nil    = redis.Nil
+	rdb atomic.Pointer[redis.Client]
+	Nil = redis.Nil
 )
 
+// closeDelay 连接池参数修改后，旧连接池延迟关闭，让正在执行的命令正常完成
+const closeDelay = 30 * time.Second
+
+func client() *redis.Client {
+	return rdb.Load()
+}
+
 // Init 初始化连接
 func Init(cfg *setting.RedisConfig) (err error) {
-	client = redis.NewClient(&redis.Options{
+	c := newClient(cfg)
+	_, err = c.Ping().Result()
+	if err != nil {
+		return err
+	}
+	rdb.Store(c)
+	setting.OnChange(reload)
+	return nil
+}
+
+func newClient(cfg *setting.RedisConfig) *redis.Client {
+	return redis.NewClient(&redis.Options{
 		Addr:         fmt.Sprintf("%s:%d", cfg.Host, cfg.Port),
 		Password:     cfg.Password,
 		DB:           cfg.DB,
 		PoolSize:     cfg.PoolSize,
 		MinIdleConns: cfg.MinIdleConns,
 	})
+}
 
-	_, err = client.Ping().Result()
-	if err != nil {
+// reload 配置修改后按新的连接池参数创建客户端并替换旧的客户端
+// go-redis创建后不能修改连接池大小，所以需要新建客户端
+func reload(oldCfg, newCfg *setting.AppConfig) {
+	o, n := oldCfg.RedisConfig, newCfg.RedisConfig
+	if o == nil || n == nil || o.PoolSize == n.PoolSize && o.MinIdleConns == n.MinIdleConns {
+		return
+	}
+	// 只有连接池参数支持热更新，连接地址等修改需要重启生效
+	cfg := *n
+	cfg.Host, cfg.Port, cfg.Password, cfg.DB = o.Host, o.Port, o.Password, o.DB
+	c := newClient(&cfg)
+	if err := c.Ping().Err(); err != nil {
+		zap.L().Error("reload redis client failed", zap.Error(err))
+		_ = c.Close()
+		return
+	}
+	old := rdb.Swap(c)
+	time.AfterFunc(closeDelay, func() { _ = old.Close() })
+	zap.L().Info("redis pool reloaded",
+		zap.Int("pool_size", cfg.PoolSize),
+		zap.Int("min_idle_conns", cfg.MinIdleConns))
+}
+
+// PoolStats 连接池的统计数据
+func PoolStats() *redis.PoolStats {
+	return client().PoolStats()
+}
+
+// Ping 检查redis是否可用，go-redis v6的命令不支持ctx，超时后不再等待结果
+func Ping(ctx context.Context) error {
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- client().Ping().Err()
+	}()
+	select {
+	case err := <-errCh:
 		return err
+	case <-ctx.Done():
+		return ctx.Err()
 	}
-	return nil
 }
 
 func Close() {
-	_ = client.Close()
-}
\ No newline at end of file
+	_ = client().Close()
+}
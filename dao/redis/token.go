@@ -0,0 +1,47 @@
+package redis
+
+import (
+	"bluebell/pkg/tracing"
+	"context"
+	"strconv"
+	"time"
+)
+
+// GetTokenVersion 查询用户当前的token版本号，不存在时为0
+func GetTokenVersion(ctx context.Context, userID int64) (_ int64, err error) {
+	_, span := tracing.Start(ctx, "redis.GetTokenVersion")
+	defer func() { tracing.End(span, err) }()
+	key := getRedisKey(keyTokenVersionPF + strconv.FormatInt(userID, 10))
+	v, err := client().Get(key).Int64()
+	if err == Nil {
+		return 0, nil
+	}
+	return v, err
+}
+
+// IncrTokenVersion 递增用户的token版本号，此前签发的所有token随之失效
+func IncrTokenVersion(ctx context.Context, userID int64) (err error) {
+	_, span := tracing.Start(ctx, "redis.IncrTokenVersion")
+	defer func() { tracing.End(span, err) }()
+	key := getRedisKey(keyTokenVersionPF + strconv.FormatInt(userID, 10))
+	return client().Incr(key).Err()
+}
+
+// SaveRefreshToken 记录新签发的refresh token
+func SaveRefreshToken(ctx context.Context, tokenID string, userID int64, expiration time.Duration) (err error) {
+	_, span := tracing.Start(ctx, "redis.SaveRefreshToken")
+	defer func() { tracing.End(span, err) }()
+	return client().Set(getRedisKey(keyRefreshTokenPF+tokenID), userID, expiration).Err()
+}
+
+// ConsumeRefreshToken 使用(删除)一个refresh token
+// 返回false表示该token不存在，即已过期或已经被使用过
+func ConsumeRefreshToken(ctx context.Context, tokenID string) (_ bool, err error) {
+	_, span := tracing.Start(ctx, "redis.ConsumeRefreshToken")
+	defer func() { tracing.End(span, err) }()
+	n, err := client().Del(getRedisKey(keyRefreshTokenPF + tokenID)).Result()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
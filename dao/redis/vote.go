@@ -1,8 +1,10 @@
 package redis
 
 import (
+	"bluebell/models"
+	"bluebell/pkg/tracing"
+	"context"
 	"errors"
-	"math"
 	"strconv"
 	"time"
 
@@ -30,6 +32,7 @@ import (
    每个贴子自发表之日起一个星期之内允许用户投票，超过一个星期就不允许再投票了。
    	1. 到期之后将redis中保存的赞成票数及反对票数存储到mysql表中
    	2. 到期之后删除那个 KeyPostVotedZSetPF
+   归档由 logic.RunVoteArchiver 定期执行
 */
 
 const (
@@ -42,8 +45,10 @@ var (
 	ErrVoteRepeated   = errors.New("不允许重复投票")
 )
 
-func CreatePost(postID, communityID int64) error {
-	pipeline := client.TxPipeline()
+func CreatePost(ctx context.Context, postID, communityID int64) (err error) {
+	_, span := tracing.Start(ctx, "redis.CreatePost")
+	defer func() { tracing.End(span, err) }()
+	pipeline := client().TxPipeline()
 	// 帖子时间
 	pipeline.ZAdd(getRedisKey(keyPostTimeZSet), redis.Z{
 		Score:  float64(time.Now().Unix()),
@@ -58,46 +63,112 @@ func CreatePost(postID, communityID int64) error {
 	// 把帖子id加到社区的set
 	cKey := getRedisKey(keyCommunitySetPF + strconv.Itoa(int(communityID)))
 	pipeline.SAdd(cKey, postID)
-	_, err := pipeline.Exec()
+	_, err = pipeline.Exec()
 	return err
 }
 
-func VoteForPost(userID, postID string, value float64) error {
-	// 1. 判断投票限制
-	// 去redis取帖子发布时间
-	postTime := client.ZScore(getRedisKey(keyPostTimeZSet), postID).Val()
-	if float64(time.Now().Unix())-postTime > oneWeekInSeconds { // 贴子发布一个星期后不能投票
-		return ErrVoteTimeExpire
+// voteScript 在redis服务端原子地完成投票的全部步骤
+// 投票限制判断、查询之前的投票记录、更新分数、记录投票必须作为一个整体执行，
+// 否则同一用户并发投票时会读到过期的投票记录，导致分数被重复累加
+// KEYS[1] 发帖时间zset  KEYS[2] 分数zset  KEYS[3] 投票记录zset
+// ARGV[1] 帖子id  ARGV[2] 用户id  ARGV[3] 投票值  ARGV[4] 当前时间  ARGV[5] 允许投票的时长  ARGV[6] 每一票的分数
+// 返回值 1:投票成功  -1:投票时间已过  -2:重复投票
+var voteScript = redis.NewScript(`
+local postTime = tonumber(redis.call('ZSCORE', KEYS[1], ARGV[1]) or '0')
+if tonumber(ARGV[4]) - postTime > tonumber(ARGV[5]) then
+	return -1
+end
+local value = tonumber(ARGV[3])
+local ov = tonumber(redis.call('ZSCORE', KEYS[3], ARGV[2]) or '0')
+if value == ov then
+	return -2
+end
+redis.call('ZINCRBY', KEYS[2], (value - ov) * tonumber(ARGV[6]), ARGV[1])
+if value == 0 then
+	redis.call('ZREM', KEYS[3], ARGV[2])
+else
+	redis.call('ZADD', KEYS[3], value, ARGV[2])
+end
+return 1
+`)
+
+func VoteForPost(ctx context.Context, userID, postID string, value float64) (err error) {
+	_, span := tracing.Start(ctx, "redis.VoteForPost")
+	defer func() { tracing.End(span, err) }()
+	keys := []string{
+		getRedisKey(keyPostTimeZSet),
+		getRedisKey(keyPostScoreZSet),
+		getRedisKey(KeyPostVotedZSetPF + postID),
 	}
-	// 2和3需要放到一个pipeline事务中操作
-	// 2. 更新帖子分数
-	// 先查看当前用户给当前帖子的投票纪录
-	ov := client.ZScore(getRedisKey(KeyPostVotedZSetPF+postID), userID).Val()
-
-	// 如果这一次投票的值和之前一样，就提示不允许重复投票
-	if value == ov {
-		return ErrVoteRepeated
+	return runVoteScript(keys, postID, userID, value)
+}
+
+// runVoteScript 执行投票脚本并把返回值转换成对应的错误
+func runVoteScript(keys []string, member, userID string, value float64) (err error) {
+	res, err := voteScript.Run(client(), keys,
+		member, userID, value, time.Now().Unix(), oneWeekInSeconds, scorePerVote).Int64()
+	if err != nil {
+		return err
 	}
-	var op float64
-	if value > ov {
-		op = 1
-	} else {
-		op = -1
+	switch res {
+	case -1:
+		return ErrVoteTimeExpire // 贴子发布一个星期后不能投票
+	case -2:
+		return ErrVoteRepeated // 如果这一次投票的值和之前一样，就提示不允许重复投票
 	}
-	diff := math.Abs(ov - value) // 计算两次投票的差值
-	pipeline := client.TxPipeline()
-	pipeline.ZIncrBy(getRedisKey(keyPostScoreZSet), op*diff*scorePerVote, postID)
-
-	// 3. 记录用户为该帖子投票的数据
-	if value == 0 {
-		pipeline.ZRem(getRedisKey(KeyPostVotedZSetPF + postID),userID)
-	} else {
-		pipeline.ZAdd(getRedisKey(KeyPostVotedZSetPF + postID),redis.Z{
-			Score: value,// 赞成还是反对
-			Member: userID,
+	return nil
+}
+
+// VoteDeadline 发帖时间早于该时间的帖子已经过了投票期
+func VoteDeadline() time.Time {
+	return time.Now().Add(-oneWeekInSeconds * time.Second)
+}
+
+// GetPostVoteRecords 查询帖子的全部投票记录
+func GetPostVoteRecords(ctx context.Context, postID int64) (records []*models.PostVoteRecord, err error) {
+	_, span := tracing.Start(ctx, "redis.GetPostVoteRecords")
+	defer func() { tracing.End(span, err) }()
+	key := getRedisKey(KeyPostVotedZSetPF + strconv.FormatInt(postID, 10))
+	zs, err := client().ZRangeWithScores(key, 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+	records = make([]*models.PostVoteRecord, 0, len(zs))
+	for _, z := range zs {
+		userID, err := strconv.ParseInt(z.Member.(string), 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, &models.PostVoteRecord{
+			PostID:    postID,
+			UserID:    userID,
+			Direction: int8(z.Score),
 		})
 	}
-	_,err := pipeline.Exec()
+	return
+}
+
+// MarkPostVoteArchiving 在写mysql之前记录正在归档的帖子，删除redis投票记录失败时由SweepArchivedVotes清理
+func MarkPostVoteArchiving(ctx context.Context, postID int64) (err error) {
+	_, span := tracing.Start(ctx, "redis.MarkPostVoteArchiving")
+	defer func() { tracing.End(span, err) }()
+	return client().SAdd(getRedisKey(keyPostArchivingSet), postID).Err()
+}
+
+// DeletePostVoteRecords 删除帖子的投票记录，同时移出正在归档的帖子集合
+func DeletePostVoteRecords(ctx context.Context, postID int64) (err error) {
+	_, span := tracing.Start(ctx, "redis.DeletePostVoteRecords")
+	defer func() { tracing.End(span, err) }()
+	pipeline := client().TxPipeline()
+	pipeline.Del(getRedisKey(KeyPostVotedZSetPF + strconv.FormatInt(postID, 10)))
+	pipeline.SRem(getRedisKey(keyPostArchivingSet), postID)
+	_, err = pipeline.Exec()
 	return err
+}
 
+// ScanArchivingPostIDs 从cursor开始遍历正在归档的帖子id，next为0时表示遍历结束
+func ScanArchivingPostIDs(ctx context.Context, cursor uint64, count int64) (ids []string, next uint64, err error) {
+	_, span := tracing.Start(ctx, "redis.ScanArchivingPostIDs")
+	defer func() { tracing.End(span, err) }()
+	return client().SScan(getRedisKey(keyPostArchivingSet), cursor, "", count).Result()
 }
@@ -0,0 +1,160 @@
+package redis
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis"
+)
+
+func setupMiniRedis(t *testing.T) *miniredis.Miniredis {
+	mr := miniredis.RunT(t)
+	rdb.Store(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+	t.Cleanup(Close)
+	return mr
+}
+
+func postScore(t *testing.T, postID int64) float64 {
+	score, err := client().ZScore(getRedisKey(keyPostScoreZSet), strconv.FormatInt(postID, 10)).Result()
+	if err != nil {
+		t.Fatalf("ZScore failed, err:%v", err)
+	}
+	return score
+}
+
+// 同一个用户并发投相同的票，只能有一次生效
+func TestVoteForPostConcurrentSameUser(t *testing.T) {
+	setupMiniRedis(t)
+	postID := int64(1)
+	if err := CreatePost(context.Background(), postID, 1); err != nil {
+		t.Fatalf("CreatePost failed, err:%v", err)
+	}
+	base := postScore(t, postID)
+
+	const n = 100
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		success  int
+		repeated int
+	)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := VoteForPost(context.Background(), "10", "1", 1)
+			mu.Lock()
+			defer mu.Unlock()
+			switch err {
+			case nil:
+				success++
+			case ErrVoteRepeated:
+				repeated++
+			default:
+				t.Errorf("VoteForPost failed, err:%v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if success != 1 || repeated != n-1 {
+		t.Fatalf("success:%d repeated:%d, want 1 and %d", success, repeated, n-1)
+	}
+	if got := postScore(t, postID); got != base+scorePerVote {
+		t.Fatalf("score:%v, want %v", got, base+scorePerVote)
+	}
+}
+
+// 多个用户并发改票，最终分数与投票记录保持一致
+func TestVoteForPostConcurrentUsers(t *testing.T) {
+	setupMiniRedis(t)
+	postID := int64(2)
+	if err := CreatePost(context.Background(), postID, 1); err != nil {
+		t.Fatalf("CreatePost failed, err:%v", err)
+	}
+	base := postScore(t, postID)
+
+	const n = 50
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		userID := strconv.Itoa(i)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// 先投赞成票再改投反对票
+			for _, v := range []float64{1, -1} {
+				if err := VoteForPost(context.Background(), userID, "2", v); err != nil {
+					t.Errorf("VoteForPost failed, err:%v", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got, want := postScore(t, postID), base-n*scorePerVote; got != want {
+		t.Fatalf("score:%v, want %v", got, want)
+	}
+	down, err := client().ZCount(getRedisKey(KeyPostVotedZSetPF+"2"), "-1", "-1").Result()
+	if err != nil {
+		t.Fatalf("ZCount failed, err:%v", err)
+	}
+	if down != n {
+		t.Fatalf("down votes:%d, want %d", down, n)
+	}
+}
+
+func TestVoteForPostExpired(t *testing.T) {
+	setupMiniRedis(t)
+	client().ZAdd(getRedisKey(keyPostTimeZSet), redis.Z{
+		Score:  float64(time.Now().Unix() - oneWeekInSeconds - 1),
+		Member: "3",
+	})
+	if err := VoteForPost(context.Background(), "10", "3", 1); err != ErrVoteTimeExpire {
+		t.Fatalf("err:%v, want %v", err, ErrVoteTimeExpire)
+	}
+}
+
+// 删除投票记录时同时移出正在归档的集合，删除前的帖子都能遍历到
+func TestScanArchivingPostIDs(t *testing.T) {
+	setupMiniRedis(t)
+	ctx := context.Background()
+	for _, postID := range []int64{1, 2, 3} {
+		if err := CreatePost(ctx, postID, 1); err != nil {
+			t.Fatalf("CreatePost failed, err:%v", err)
+		}
+		if err := VoteForPost(ctx, "10", strconv.FormatInt(postID, 10), 1); err != nil {
+			t.Fatalf("VoteForPost failed, err:%v", err)
+		}
+		if err := MarkPostVoteArchiving(ctx, postID); err != nil {
+			t.Fatalf("MarkPostVoteArchiving failed, err:%v", err)
+		}
+	}
+	if err := DeletePostVoteRecords(ctx, 2); err != nil {
+		t.Fatalf("DeletePostVoteRecords failed, err:%v", err)
+	}
+	if n := client().Exists(getRedisKey(KeyPostVotedZSetPF + "2")).Val(); n != 0 {
+		t.Fatalf("vote records of post 2 still exist")
+	}
+
+	got := make(map[string]bool)
+	var cursor uint64
+	for {
+		ids, next, err := ScanArchivingPostIDs(ctx, cursor, 1)
+		if err != nil {
+			t.Fatalf("ScanArchivingPostIDs failed, err:%v", err)
+		}
+		for _, id := range ids {
+			got[id] = true
+		}
+		if cursor = next; cursor == 0 {
+			break
+		}
+	}
+	if len(got) != 2 || !got["1"] || !got["3"] {
+		t.Fatalf("ScanArchivingPostIDs got %v, want [1 3]", got)
+	}
+}
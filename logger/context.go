@@ -0,0 +1,28 @@
+package logger
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+type ctxLoggerKey struct{}
+
+// NewContext 返回携带lg的ctx，之后通过FromContext取出
+func NewContext(ctx context.Context, lg *zap.Logger) context.Context {
+	return context.WithValue(ctx, ctxLoggerKey{}, lg)
+}
+
+// FromContext 获取ctx中的logger，用于给同一个请求的所有日志带上request_id等字段
+// ctx中没有logger时(如后台任务)返回全局logger
+func FromContext(ctx context.Context) *zap.Logger {
+	if lg, ok := ctx.Value(ctxLoggerKey{}).(*zap.Logger); ok {
+		return lg
+	}
+	return zap.L()
+}
+
+// With 给ctx中的logger追加字段
+func With(ctx context.Context, fields ...zap.Field) context.Context {
+	return NewContext(ctx, FromContext(ctx).With(fields...))
+}
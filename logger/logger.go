@@ -16,14 +16,16 @@ import (
 	"gopkg.in/natefinch/lumberjack.v2"
 )
 
-var lg *zap.Logger
+var (
+	lg    *zap.Logger
+	level = zap.NewAtomicLevel() // 日志级别，修改配置文件后立即生效
+)
 
 // 初始化lg
 func Init(cfg *setting.LogConfig, mode string) (err error) {
 	writeSyncer := getLogWriter(cfg.Filename,cfg.MaxSize,cfg.MaxBackups,cfg.MaxAge)
 	encoder := getEncoder()
-	var l = new(zapcore.Level)
-	err = l.UnmarshalText([]byte(cfg.Level))
+	err = level.UnmarshalText([]byte(cfg.Level))
 	if err != nil {
 		return 
 	}
@@ -32,20 +34,34 @@ func Init(cfg *setting.LogConfig, mode string) (err error) {
 		// 进入开发模式，日志输出到终端
 		consoleEncoder := zapcore.NewConsoleEncoder(zap.NewDevelopmentEncoderConfig())
 		core = zapcore.NewTee(
-			zapcore.NewCore(encoder,writeSyncer,l),
+			zapcore.NewCore(encoder,writeSyncer,level),
 			zapcore.NewCore(consoleEncoder,zapcore.Lock(os.Stdout),zapcore.DebugLevel),
 		)
 	}else {
-		core = zapcore.NewCore(encoder,writeSyncer,l)
+		core = zapcore.NewCore(encoder,writeSyncer,level)
 	}
 
 	lg = zap.New(core,zap.AddCaller())
 
 	zap.ReplaceGlobals(lg)
 	zap.L().Info("init logger success")
+	setting.OnChange(reload)
 	return
 }
 
+// reload 配置修改后更新日志级别，其他日志配置需要重启生效
+func reload(oldCfg, newCfg *setting.AppConfig) {
+	// 配置文件中删除了log时保持原来的日志级别
+	if oldCfg.LogConfig == nil || newCfg.LogConfig == nil || oldCfg.LogConfig.Level == newCfg.LogConfig.Level {
+		return
+	}
+	if err := level.UnmarshalText([]byte(newCfg.LogConfig.Level)); err != nil {
+		zap.L().Error("reload log level failed", zap.String("level", newCfg.LogConfig.Level), zap.Error(err))
+		return
+	}
+	zap.L().Info("log level changed", zap.String("level", newCfg.LogConfig.Level))
+}
+
 func getEncoder() zapcore.Encoder {
 	encoderConfig := zap.NewProductionEncoderConfig()
 	encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
@@ -75,7 +91,8 @@ func GinLogger() gin.HandlerFunc {
 		c.Next()
 
 		cost := time.Since(start)
-		lg.Info(path,
+		// 使用请求的logger，日志带上request_id、trace_id和user_id
+		FromContext(c.Request.Context()).Info(path,
 			zap.Int("status", c.Writer.Status()),
 			zap.String("method", c.Request.Method),
 			zap.String("path", path),
@@ -105,6 +122,7 @@ func GinRecovery(stack bool) gin.HandlerFunc {
 				}
 
 				httpRequest, _ := httputil.DumpRequest(c.Request, false)
+				lg := FromContext(c.Request.Context())
 				if brokenPipe {
 					lg.Error(c.Request.URL.Path,
 						zap.Any("error", err),
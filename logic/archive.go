@@ -0,0 +1,116 @@
+package logic
+
+import (
+	"bluebell/dao/mysql"
+	"bluebell/dao/redis"
+	"bluebell/logger"
+	"bluebell/models"
+	"bluebell/pkg/tracing"
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// RunVoteArchiver 定期把已过投票期的帖子的投票数据从redis归档到mysql，直到ctx被取消
+func RunVoteArchiver(ctx context.Context, interval time.Duration, batchSize int) {
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		n, err := ArchiveExpiredVotes(ctx, batchSize)
+		if err != nil {
+			logger.FromContext(ctx).Error("ArchiveExpiredVotes failed", zap.Error(err))
+		} else if n > 0 {
+			logger.FromContext(ctx).Info("archive expired votes", zap.Int("posts", n))
+		}
+		n, err = SweepArchivedVotes(ctx, batchSize)
+		if err != nil {
+			logger.FromContext(ctx).Error("SweepArchivedVotes failed", zap.Error(err))
+		} else if n > 0 {
+			logger.FromContext(ctx).Info("sweep archived votes", zap.Int("posts", n))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ArchiveExpiredVotes 归档一批已过投票期的帖子，返回归档的帖子数
+// 先写mysql再删除redis中的投票记录，写mysql失败时下次执行会重新归档
+// 写mysql前先把帖子记入正在归档的集合，mysql写入成功后帖子不会再被查出，
+// 删除redis失败留下的记录由SweepArchivedVotes根据这个集合清理
+func ArchiveExpiredVotes(ctx context.Context, batchSize int) (n int, err error) {
+	ctx, span := tracing.Start(ctx, "logic.ArchiveExpiredVotes")
+	defer func() { tracing.End(span, err) }()
+	ids, err := mysql.GetUnarchivedPostIDs(ctx, redis.VoteDeadline(), batchSize)
+	if err != nil {
+		return
+	}
+	for _, id := range ids {
+		if err = archivePostVote(ctx, id); err != nil {
+			logger.FromContext(ctx).Error("archivePostVote failed", zap.Int64("post_id", id), zap.Error(err))
+			return
+		}
+		n++
+	}
+	return
+}
+
+func archivePostVote(ctx context.Context, postID int64) error {
+	records, err := redis.GetPostVoteRecords(ctx, postID)
+	if err != nil {
+		return err
+	}
+	vote := &models.PostVote{PostID: postID}
+	for _, r := range records {
+		if r.Direction > 0 {
+			vote.UpCount++
+		} else {
+			vote.DownCount++
+		}
+	}
+	if err := redis.MarkPostVoteArchiving(ctx, postID); err != nil {
+		return err
+	}
+	if err := mysql.ArchivePostVote(ctx, vote, records); err != nil {
+		return err
+	}
+	return redis.DeletePostVoteRecords(ctx, postID)
+}
+
+// SweepArchivedVotes 遍历正在归档的帖子，删除已归档帖子遗留的投票记录，返回清理的帖子数
+// 只检查归档时记下的帖子，不需要扫描整个keyspace
+func SweepArchivedVotes(ctx context.Context, batchSize int) (n int, err error) {
+	ctx, span := tracing.Start(ctx, "logic.SweepArchivedVotes")
+	defer func() { tracing.End(span, err) }()
+	var (
+		cursor uint64
+		ids    []string
+	)
+	for {
+		ids, cursor, err = redis.ScanArchivingPostIDs(ctx, cursor, int64(batchSize))
+		if err != nil {
+			return
+		}
+		if len(ids) > 0 {
+			var votes []*models.PostVote
+			if votes, err = mysql.GetPostVotesByIDs(ctx, ids); err != nil {
+				return
+			}
+			for _, vote := range votes {
+				if err = redis.DeletePostVoteRecords(ctx, vote.PostID); err != nil {
+					return
+				}
+				n++
+			}
+		}
+		if cursor == 0 {
+			return
+		}
+	}
+}
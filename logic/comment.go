@@ -0,0 +1,209 @@
+package logic
+
+import (
+	"bluebell/dao/cache"
+	"bluebell/dao/mysql"
+	"bluebell/dao/redis"
+	"bluebell/logger"
+	"bluebell/models"
+	"bluebell/pkg/metrics"
+	"bluebell/pkg/snowflake"
+	"bluebell/pkg/tracing"
+	"context"
+	"sort"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// CreateComment 发表评论，ParentID不为空时表示回复同一帖子下的另一条评论
+func CreateComment(ctx context.Context, userID, postID int64, p *models.ParamComment) (comment *models.Comment, err error) {
+	ctx, span := tracing.Start(ctx, "logic.CreateComment")
+	defer func() { tracing.End(span, err) }()
+	// 帖子必须存在且没有被锁定
+	post, err := cache.GetPostByID(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+	if post.Status == models.PostStatusLocked {
+		return nil, ErrorPostLocked
+	}
+	if p.ParentID != 0 {
+		parent, err := mysql.GetCommentByID(ctx, p.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		if parent.PostID != postID {
+			return nil, mysql.ErrorInvalidID
+		}
+	}
+	comment = &models.Comment{
+		ID:         snowflake.GenID(),
+		PostID:     postID,
+		ParentID:   p.ParentID,
+		AuthorID:   userID,
+		Content:    p.Content,
+		CreateTime: time.Now(),
+	}
+	if err = mysql.CreateComment(ctx, comment); err != nil {
+		return nil, err
+	}
+	err = redis.CreateComment(ctx, comment.ID, postID)
+	return
+}
+
+// GetCommentTree 按层级返回帖子下的评论
+// 第一层评论分页，每一层按时间或分数排序，超过Depth层的回复不展开
+// 只查询当前页的评论及其展开的回复，不加载帖子下的全部评论
+func GetCommentTree(ctx context.Context, postID int64, p *models.ParamCommentList) (data []*models.ApiCommentDetail, err error) {
+	ctx, span := tracing.Start(ctx, "logic.GetCommentTree")
+	defer func() { tracing.End(span, err) }()
+	// 第一层只查询id，排序分页后再查询当前页的评论内容
+	ids, err := mysql.GetCommentIDsByParent(ctx, postID, p.ParentID)
+	if err != nil {
+		return
+	}
+	if p.Order == models.OrderScore {
+		scores, err := redis.GetCommentScores(ctx, postID, ids)
+		if err != nil {
+			return nil, err
+		}
+		sort.SliceStable(ids, func(i, j int) bool {
+			return scores[ids[i]] > scores[ids[j]]
+		})
+	}
+	start := (p.Page - 1) * p.Size
+	if start < 0 || p.Size <= 0 || start >= int64(len(ids)) {
+		return []*models.ApiCommentDetail{}, nil
+	}
+	end := min(start+p.Size, int64(len(ids)))
+	roots, err := mysql.GetCommentsByIDs(ctx, ids[start:end])
+	if err != nil {
+		return
+	}
+
+	data = newCommentNodes(roots)
+	visible := append([]*models.ApiCommentDetail(nil), data...)
+	// 逐层查询回复，最后一层只统计回复数
+	for level, depth := data, p.Depth; len(level) > 0; depth-- {
+		parentIDs := make([]int64, 0, len(level))
+		for _, node := range level {
+			parentIDs = append(parentIDs, node.ID)
+		}
+		if depth <= 1 {
+			counts, err := mysql.CountCommentReplies(ctx, postID, parentIDs)
+			if err != nil {
+				return nil, err
+			}
+			for _, node := range level {
+				node.ReplyNum = counts[node.ID]
+			}
+			break
+		}
+		if level, err = loadCommentReplies(ctx, postID, p.Order, level, parentIDs); err != nil {
+			return nil, err
+		}
+		visible = append(visible, level...)
+	}
+	if err = fillCommentDetail(ctx, visible); err != nil {
+		return nil, err
+	}
+	return
+}
+
+func newCommentNodes(list []*models.Comment) []*models.ApiCommentDetail {
+	nodes := make([]*models.ApiCommentDetail, 0, len(list))
+	for _, c := range list {
+		nodes = append(nodes, &models.ApiCommentDetail{Comment: c})
+	}
+	return nodes
+}
+
+// loadCommentReplies 查询parents的直接回复并挂到对应的评论下，返回下一层的评论
+func loadCommentReplies(ctx context.Context, postID int64, order string,
+	parents []*models.ApiCommentDetail, parentIDs []int64) ([]*models.ApiCommentDetail, error) {
+	replies, err := mysql.GetCommentsByParentIDs(ctx, postID, parentIDs)
+	if err != nil {
+		return nil, err
+	}
+	// 查询结果已经按时间从新到旧排好序
+	if order == models.OrderScore {
+		ids := make([]int64, 0, len(replies))
+		for _, c := range replies {
+			ids = append(ids, c.ID)
+		}
+		scores, err := redis.GetCommentScores(ctx, postID, ids)
+		if err != nil {
+			return nil, err
+		}
+		sort.SliceStable(replies, func(i, j int) bool {
+			return scores[replies[i].ID] > scores[replies[j].ID]
+		})
+	}
+	children := make(map[int64][]*models.ApiCommentDetail, len(parents))
+	next := newCommentNodes(replies)
+	for _, node := range next {
+		children[node.ParentID] = append(children[node.ParentID], node)
+	}
+	for _, node := range parents {
+		node.Children = children[node.ID]
+		node.ReplyNum = len(node.Children)
+	}
+	return next, nil
+}
+
+// fillCommentDetail 填充评论的作者名称和赞成票数
+func fillCommentDetail(ctx context.Context, nodes []*models.ApiCommentDetail) error {
+	if len(nodes) == 0 {
+		return nil
+	}
+	ids := make([]int64, 0, len(nodes))
+	for _, node := range nodes {
+		ids = append(ids, node.ID)
+	}
+	voteData, err := redis.GetCommentVoteData(ctx, ids)
+	if err != nil {
+		return err
+	}
+	authorIDs := make([]int64, 0, len(nodes))
+	for _, node := range nodes {
+		authorIDs = append(authorIDs, node.AuthorID)
+	}
+	loader := newPostLoader()
+	if err := loader.loadUsers(ctx, authorIDs); err != nil {
+		return err
+	}
+	for idx, node := range nodes {
+		node.VoteNum = voteData[idx]
+		if user, ok := loader.user(node.AuthorID); ok {
+			node.AuthorName = user.Username
+		}
+	}
+	return nil
+}
+
+// VoteForComment 为评论投票
+func VoteForComment(ctx context.Context, userID int64, p *models.ParamCommentVoteData) (err error) {
+	ctx, span := tracing.Start(ctx, "logic.VoteForComment")
+	defer func() { tracing.End(span, err) }()
+	commentID, err := strconv.ParseInt(p.CommentID, 10, 64)
+	if err != nil {
+		return mysql.ErrorInvalidID
+	}
+	comment, err := mysql.GetCommentByID(ctx, commentID)
+	if err != nil {
+		return err
+	}
+	logger.FromContext(ctx).Debug("VoteForComment",
+		zap.Int64("userID", userID),
+		zap.String("commentID", p.CommentID),
+		zap.Int8("direction", p.Direction))
+	err = redis.VoteForComment(ctx, strconv.FormatInt(userID, 10),
+		strconv.FormatInt(comment.PostID, 10), p.CommentID, float64(p.Direction))
+	if err != nil {
+		return err
+	}
+	metrics.VotesCast.WithLabelValues("comment", metrics.VoteDirection(p.Direction)).Inc()
+	return nil
+}
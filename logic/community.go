@@ -1,15 +1,88 @@
 package logic
 
 import (
+	"bluebell/dao/cache"
 	"bluebell/dao/mysql"
 	"bluebell/models"
+	"bluebell/pkg/snowflake"
+	"bluebell/pkg/tracing"
+	"context"
 )
 
-func GetCommunityList()([]*models.Community,error) {
+func GetCommunityList(ctx context.Context) (_ []*models.Community, err error) {
+	ctx, span := tracing.Start(ctx, "logic.GetCommunityList")
+	defer func() { tracing.End(span, err) }()
 	// 查数据库 查找到所以的community 并返回
-	return mysql.GetCommunityList()
+	return mysql.GetCommunityList(ctx)
 }
 
-func GetCommunityDetail(id int64) (*models.CommunityDetail,error) {
-	return mysql.GetCommunityDetailByID(id)
-}
\ No newline at end of file
+func GetCommunityDetail(ctx context.Context, id int64) (_ *models.CommunityDetail, err error) {
+	ctx, span := tracing.Start(ctx, "logic.GetCommunityDetail")
+	defer func() { tracing.End(span, err) }()
+	return cache.GetCommunityDetailByID(ctx, id)
+}
+
+// CreateCommunity 创建社区，创建人成为社区的所有者和版主
+func CreateCommunity(ctx context.Context, userID int64, p *models.ParamCommunity) (community *models.CommunityDetail, err error) {
+	ctx, span := tracing.Start(ctx, "logic.CreateCommunity")
+	defer func() { tracing.End(span, err) }()
+	if err = mysql.CheckCommunityNameExist(ctx, p.Name, 0); err != nil {
+		return
+	}
+	user, err := mysql.GetUserById(ctx, userID)
+	if err != nil {
+		return
+	}
+	community = &models.CommunityDetail{
+		ID:           snowflake.GenID(),
+		Name:         p.Name,
+		Introduction: p.Introduction,
+		OwnerID:      userID,
+		Status:       models.CommunityStatusNormal,
+	}
+	if err = mysql.CreateCommunity(ctx, community); err != nil {
+		return nil, err
+	}
+	if user.Role == models.RoleUser {
+		err = setUserRole(ctx, userID, user.Role, models.RoleModerator)
+	}
+	return
+}
+
+// UpdateCommunity 修改社区信息，社区的版主和管理员可以修改
+func UpdateCommunity(ctx context.Context, userID int64, role models.Role, id int64, p *models.ParamCommunity) (err error) {
+	ctx, span := tracing.Start(ctx, "logic.UpdateCommunity")
+	defer func() { tracing.End(span, err) }()
+	community, err := mysql.GetCommunityDetailByID(ctx, id)
+	if err != nil {
+		return
+	}
+	if err = checkCommunityPermission(ctx, userID, role, id); err != nil {
+		return
+	}
+	if err = mysql.CheckCommunityNameExist(ctx, p.Name, id); err != nil {
+		return
+	}
+	community.Name = p.Name
+	community.Introduction = p.Introduction
+	if err = mysql.UpdateCommunity(ctx, community); err != nil {
+		return
+	}
+	return cache.InvalidateCommunity(ctx, id)
+}
+
+// ArchiveCommunity 归档社区，归档后不能再发帖
+func ArchiveCommunity(ctx context.Context, userID int64, role models.Role, id int64) (err error) {
+	ctx, span := tracing.Start(ctx, "logic.ArchiveCommunity")
+	defer func() { tracing.End(span, err) }()
+	if _, err = mysql.GetCommunityDetailByID(ctx, id); err != nil {
+		return
+	}
+	if err = checkCommunityPermission(ctx, userID, role, id); err != nil {
+		return
+	}
+	if err = mysql.SetCommunityStatus(ctx, id, models.CommunityStatusArchived); err != nil {
+		return
+	}
+	return cache.InvalidateCommunity(ctx, id)
+}
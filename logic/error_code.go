@@ -0,0 +1,23 @@
+package logic
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrorInvalidToken      = errors.New("无效的token")
+	ErrorNoPermission      = errors.New("没有权限")
+	ErrorUserBanned        = errors.New("用户已被封禁")
+	ErrorPostLocked        = errors.New("帖子已被锁定")
+	ErrorCommunityArchived = errors.New("社区已归档")
+)
+
+// LoginLockedError 登录失败次数过多，暂时禁止登录
+type LoginLockedError struct {
+	RetryAfter time.Duration // 解除锁定的剩余时间
+}
+
+func (e *LoginLockedError) Error() string {
+	return "登录失败次数过多，请稍后再试"
+}
@@ -0,0 +1,106 @@
+package logic
+
+import (
+	"bluebell/dao/mysql"
+	"bluebell/dao/redis"
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 健康检查的状态
+const (
+	HealthStatusUp   = "up"
+	HealthStatusDown = "down"
+)
+
+// pingTimeout 检查每个依赖的超时时间
+const pingTimeout = 2 * time.Second
+
+var (
+	draining atomic.Bool
+	workers  atomic.Pointer[func() map[string]bool]
+)
+
+// SetDraining 收到退出信号后调用，之后/readyz返回503，负载均衡不再分配新请求
+func SetDraining() {
+	draining.Store(true)
+}
+
+// SetWorkersAlive 设置查询后台任务是否在运行的函数
+func SetWorkersAlive(fn func() map[string]bool) {
+	workers.Store(&fn)
+}
+
+// DependencyStatus 一个依赖的检查结果
+type DependencyStatus struct {
+	Status  string `json:"status"`
+	Latency string `json:"latency,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// ReadinessReport 就绪检查的结果
+type ReadinessReport struct {
+	Status   string                       `json:"status"`
+	Draining bool                         `json:"draining"`
+	Checks   map[string]*DependencyStatus `json:"checks"`
+}
+
+// CheckReadiness 并发检查mysql、redis及后台任务，任意一项不可用或正在退出时返回未就绪
+func CheckReadiness(ctx context.Context) *ReadinessReport {
+	report := &ReadinessReport{
+		Status:   HealthStatusUp,
+		Draining: draining.Load(),
+		Checks:   make(map[string]*DependencyStatus),
+	}
+	pings := map[string]func(context.Context) error{
+		"mysql": mysql.Ping,
+		"redis": redis.Ping,
+	}
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+	)
+	for name, ping := range pings {
+		wg.Add(1)
+		go func(name string, ping func(context.Context) error) {
+			defer wg.Done()
+			status := checkDependency(ctx, ping)
+			mu.Lock()
+			report.Checks[name] = status
+			mu.Unlock()
+		}(name, ping)
+	}
+	wg.Wait()
+
+	if fn := workers.Load(); fn != nil {
+		for name, alive := range (*fn)() {
+			status := &DependencyStatus{Status: HealthStatusUp}
+			if !alive {
+				status = &DependencyStatus{Status: HealthStatusDown, Error: "worker stopped"}
+			}
+			report.Checks["worker:"+name] = status
+		}
+	}
+
+	for _, status := range report.Checks {
+		if status.Status != HealthStatusUp {
+			report.Status = HealthStatusDown
+		}
+	}
+	if report.Draining {
+		report.Status = HealthStatusDown
+	}
+	return report
+}
+
+func checkDependency(ctx context.Context, ping func(context.Context) error) *DependencyStatus {
+	ctx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+	start := time.Now()
+	if err := ping(ctx); err != nil {
+		return &DependencyStatus{Status: HealthStatusDown, Error: err.Error()}
+	}
+	return &DependencyStatus{Status: HealthStatusUp, Latency: time.Since(start).String()}
+}
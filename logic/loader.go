@@ -0,0 +1,94 @@
+package logic
+
+import (
+	"bluebell/dao/cache"
+	"bluebell/models"
+	"context"
+)
+
+// postLoader 组装帖子及评论列表时批量加载作者和社区信息
+// 同一个loader内相同的id只会查询一次，每组装一次列表使用一个新的loader
+// 数据先从缓存读取，缓存未命中的id再批量查询mysql
+type postLoader struct {
+	users       map[int64]*models.User
+	communities map[int64]*models.CommunityDetail
+}
+
+func newPostLoader() *postLoader {
+	return &postLoader{
+		users:       make(map[int64]*models.User),
+		communities: make(map[int64]*models.CommunityDetail),
+	}
+}
+
+// loadUsers 批量查询尚未加载过的用户
+func (l *postLoader) loadUsers(ctx context.Context, ids []int64) error {
+	missing := make([]int64, 0, len(ids))
+	seen := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		if _, ok := l.users[id]; ok || seen[id] {
+			continue
+		}
+		seen[id] = true
+		missing = append(missing, id)
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	users, err := cache.GetUsersByIDs(ctx, missing)
+	if err != nil {
+		return err
+	}
+	for id, u := range users {
+		l.users[id] = u
+	}
+	return nil
+}
+
+// loadCommunities 批量查询尚未加载过的社区
+func (l *postLoader) loadCommunities(ctx context.Context, ids []int64) error {
+	missing := make([]int64, 0, len(ids))
+	seen := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		if _, ok := l.communities[id]; ok || seen[id] {
+			continue
+		}
+		seen[id] = true
+		missing = append(missing, id)
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	communities, err := cache.GetCommunitiesByIDs(ctx, missing)
+	if err != nil {
+		return err
+	}
+	for id, c := range communities {
+		l.communities[id] = c
+	}
+	return nil
+}
+
+// loadPosts 加载帖子的作者和社区
+func (l *postLoader) loadPosts(ctx context.Context, posts []*models.Post) error {
+	authorIDs := make([]int64, 0, len(posts))
+	communityIDs := make([]int64, 0, len(posts))
+	for _, post := range posts {
+		authorIDs = append(authorIDs, post.AuthorID)
+		communityIDs = append(communityIDs, int64(post.CommunityID))
+	}
+	if err := l.loadUsers(ctx, authorIDs); err != nil {
+		return err
+	}
+	return l.loadCommunities(ctx, communityIDs)
+}
+
+func (l *postLoader) user(id int64) (*models.User, bool) {
+	u, ok := l.users[id]
+	return u, ok
+}
+
+func (l *postLoader) community(id int64) (*models.CommunityDetail, bool) {
+	c, ok := l.communities[id]
+	return c, ok
+}
@@ -0,0 +1,87 @@
+package logic
+
+import (
+	"bluebell/dao/redis"
+	"bluebell/logger"
+	"bluebell/setting"
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// checkLoginLock 用户名或ip被锁定时拒绝登录
+// redis不可用时放行，不影响正常登录
+func checkLoginLock(ctx context.Context, username, ip string) error {
+	d, err := redis.GetLoginLock(ctx, username, ip)
+	if err != nil {
+		logger.FromContext(ctx).Error("redis.GetLoginLock failed", zap.Error(err))
+		return nil
+	}
+	if d > 0 {
+		return &LoginLockedError{RetryAfter: d}
+	}
+	return nil
+}
+
+// recordLoginFailure 记录登录失败，用户名和ip分别计数，超过允许的次数后锁定
+func recordLoginFailure(ctx context.Context, username, ip string) {
+	cfg := setting.Get().LoginConfig
+	window := time.Duration(cfg.Window) * time.Second
+	for _, s := range []struct {
+		subject string
+		id      string
+		free    int
+	}{
+		{redis.LoginSubjectUser, username, cfg.UserFreeAttempts},
+		{redis.LoginSubjectIP, ip, cfg.IPFreeAttempts},
+	} {
+		fails, err := redis.RecordLoginFailure(ctx, s.subject, s.id, window)
+		if err != nil {
+			// 一种计数失败不影响另一种计数
+			logger.FromContext(ctx).Error("redis.RecordLoginFailure failed", zap.String("subject", s.subject), zap.Error(err))
+			continue
+		}
+		d := loginLockDuration(fails, s.free, cfg)
+		if d <= 0 {
+			continue
+		}
+		if err := redis.LockLogin(ctx, s.subject, s.id, d); err != nil {
+			logger.FromContext(ctx).Error("redis.LockLogin failed", zap.Error(err))
+			continue
+		}
+		logger.FromContext(ctx).Warn("login locked",
+			zap.String("subject", s.subject),
+			zap.String("id", s.id),
+			zap.String("username", username),
+			zap.String("ip", ip),
+			zap.Int64("fails", fails),
+			zap.Duration("duration", d))
+	}
+}
+
+// loginLockDuration 连续失败fails次之后需要锁定的时间
+// 超过允许的次数后从base_delay开始每次翻倍，最长max_delay
+func loginLockDuration(fails int64, free int, cfg *setting.LoginConfig) time.Duration {
+	over := fails - int64(free)
+	if over <= 0 {
+		return 0
+	}
+	maxDelay := time.Duration(cfg.MaxDelay) * time.Second
+	d := time.Duration(cfg.BaseDelay) * time.Second
+	for i := int64(1); i < over && d < maxDelay; i++ {
+		d *= 2
+	}
+	if d > maxDelay {
+		d = maxDelay
+	}
+	return d
+}
+
+// clearLoginFailure 登录成功后清除该用户名的失败计数
+// ip的计数不清除，防止用一个自己的账号反复重置计数
+func clearLoginFailure(ctx context.Context, username string) {
+	if err := redis.ClearLoginFailure(ctx, redis.LoginSubjectUser, username); err != nil {
+		logger.FromContext(ctx).Error("redis.ClearLoginFailure failed", zap.Error(err))
+	}
+}
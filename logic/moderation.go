@@ -0,0 +1,153 @@
+package logic
+
+import (
+	"bluebell/dao/cache"
+	"bluebell/dao/mysql"
+	"bluebell/dao/redis"
+	"bluebell/logger"
+	"bluebell/models"
+	"bluebell/pkg/tracing"
+	"context"
+
+	"go.uber.org/zap"
+)
+
+// checkCommunityPermission 判断用户能否管理指定社区
+// 管理员可以管理所有社区，版主只能管理被指派的社区
+func checkCommunityPermission(ctx context.Context, userID int64, role models.Role, communityID int64) error {
+	if role == models.RoleAdmin {
+		return nil
+	}
+	if role != models.RoleModerator {
+		return ErrorNoPermission
+	}
+	ok, err := mysql.IsCommunityModerator(ctx, communityID, userID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrorNoPermission
+	}
+	return nil
+}
+
+// RemovePost 版主或管理员删除帖子
+func RemovePost(ctx context.Context, operatorID int64, role models.Role, pid int64) (err error) {
+	ctx, span := tracing.Start(ctx, "logic.RemovePost")
+	defer func() { tracing.End(span, err) }()
+	post, err := mysql.GetPostById(ctx, pid)
+	if err != nil {
+		return
+	}
+	if err = checkCommunityPermission(ctx, operatorID, role, int64(post.CommunityID)); err != nil {
+		return
+	}
+	if err = mysql.DeletePost(ctx, pid); err != nil {
+		return
+	}
+	if err = cache.InvalidatePost(ctx, pid); err != nil {
+		return
+	}
+	logger.FromContext(ctx).Info("post removed by moderator",
+		zap.Int64("post_id", pid),
+		zap.Int64("operator_id", operatorID))
+	return redis.DeletePost(ctx, pid, int64(post.CommunityID))
+}
+
+// LockPost 锁定或解锁帖子，锁定后不能再评论和编辑
+func LockPost(ctx context.Context, operatorID int64, role models.Role, pid int64, locked bool) (err error) {
+	ctx, span := tracing.Start(ctx, "logic.LockPost")
+	defer func() { tracing.End(span, err) }()
+	post, err := mysql.GetPostById(ctx, pid)
+	if err != nil {
+		return
+	}
+	if err = checkCommunityPermission(ctx, operatorID, role, int64(post.CommunityID)); err != nil {
+		return
+	}
+	status := models.PostStatusNormal
+	if locked {
+		status = models.PostStatusLocked
+	}
+	if err = mysql.SetPostStatus(ctx, pid, status); err != nil {
+		return
+	}
+	return cache.InvalidatePost(ctx, pid)
+}
+
+// BanUser 封禁或解封用户，封禁后该用户已签发的token立即失效
+func BanUser(ctx context.Context, operatorID, userID int64, banned bool) (err error) {
+	ctx, span := tracing.Start(ctx, "logic.BanUser")
+	defer func() { tracing.End(span, err) }()
+	status := models.UserStatusNormal
+	if banned {
+		status = models.UserStatusBanned
+	}
+	if err = mysql.SetUserStatus(ctx, userID, status); err != nil {
+		return
+	}
+	if err = cache.InvalidateUser(ctx, userID); err != nil {
+		return
+	}
+	logger.FromContext(ctx).Info("user status changed",
+		zap.Int64("user_id", userID),
+		zap.Bool("banned", banned),
+		zap.Int64("operator_id", operatorID))
+	return redis.IncrTokenVersion(ctx, userID)
+}
+
+// AddModerator 指派社区版主
+func AddModerator(ctx context.Context, communityID, userID int64) (err error) {
+	ctx, span := tracing.Start(ctx, "logic.AddModerator")
+	defer func() { tracing.End(span, err) }()
+	if _, err = mysql.GetCommunityDetailByID(ctx, communityID); err != nil {
+		return
+	}
+	user, err := mysql.GetUserById(ctx, userID)
+	if err != nil {
+		return
+	}
+	if err = mysql.AddCommunityModerator(ctx, communityID, userID); err != nil {
+		return
+	}
+	if user.Role != models.RoleUser {
+		return
+	}
+	return setUserRole(ctx, userID, user.Role, models.RoleModerator)
+}
+
+// RemoveModerator 撤销社区版主，不再管理任何社区时恢复为普通用户
+func RemoveModerator(ctx context.Context, communityID, userID int64) (err error) {
+	ctx, span := tracing.Start(ctx, "logic.RemoveModerator")
+	defer func() { tracing.End(span, err) }()
+	if err = mysql.RemoveCommunityModerator(ctx, communityID, userID); err != nil {
+		return
+	}
+	user, err := mysql.GetUserById(ctx, userID)
+	if err != nil {
+		return
+	}
+	if user.Role != models.RoleModerator {
+		return
+	}
+	count, err := mysql.CountModeratedCommunities(ctx, userID)
+	if err != nil || count > 0 {
+		return
+	}
+	return setUserRole(ctx, userID, user.Role, models.RoleUser)
+}
+
+// setUserRole 修改用户角色，token中的角色在刷新token时更新
+// 提升角色不影响已登录的会话；降低角色时使旧token立即失效，避免继续使用原来的权限
+func setUserRole(ctx context.Context, userID int64, oldRole, role models.Role) error {
+	if err := mysql.SetUserRole(ctx, userID, role); err != nil {
+		return err
+	}
+	if err := cache.InvalidateUser(ctx, userID); err != nil {
+		return err
+	}
+	if role >= oldRole {
+		return nil
+	}
+	return redis.IncrTokenVersion(ctx, userID)
+}
@@ -1,49 +1,123 @@
 package logic
 
 import (
+	"bluebell/dao/cache"
 	"bluebell/dao/mysql"
 	"bluebell/dao/redis"
+	"bluebell/logger"
 	"bluebell/models"
+	"bluebell/pkg/metrics"
 	"bluebell/pkg/snowflake"
+	"bluebell/pkg/tracing"
+	"context"
+	"strconv"
 
 	"go.uber.org/zap"
 )
 
-func CreatePost(p *models.Post) (err error) {
+func CreatePost(ctx context.Context, p *models.Post) (err error) {
+	ctx, span := tracing.Start(ctx, "logic.CreatePost")
+	defer func() { tracing.End(span, err) }()
+	// 0. 社区必须存在且没有归档
+	community, err := cache.GetCommunityDetailByID(ctx, int64(p.CommunityID))
+	if err != nil {
+		return err
+	}
+	if community.Status == models.CommunityStatusArchived {
+		return ErrorCommunityArchived
+	}
 	// 1. 生成post id
 	p.ID = snowflake.GenID()
 	// 2. 保存到数据库
-	err = mysql.CreatePost(p)
+	err = mysql.CreatePost(ctx, p)
 	if err != nil {
 		return err
 	}
-	err = redis.CreatePost(p.ID, p.CommunityID)
+	if err = redis.CreatePost(ctx, p.ID, int64(p.CommunityID)); err != nil {
+		return err
+	}
+	metrics.PostsCreated.Inc()
 	return
 }
 
+// UpdatePost 编辑帖子，只有作者本人可以编辑
+func UpdatePost(ctx context.Context, userID, pid int64, p *models.ParamUpdatePost) (err error) {
+	ctx, span := tracing.Start(ctx, "logic.UpdatePost")
+	defer func() { tracing.End(span, err) }()
+	post, err := mysql.GetPostById(ctx, pid)
+	if err != nil {
+		return
+	}
+	if post.AuthorID != userID {
+		return ErrorNoPermission
+	}
+	if post.Status == models.PostStatusLocked {
+		return ErrorPostLocked
+	}
+	post.Title = p.Title
+	post.Content = p.Content
+	if err = mysql.UpdatePost(ctx, post, userID); err != nil {
+		return
+	}
+	return cache.InvalidatePost(ctx, pid)
+}
+
+// DeletePost 删除帖子，只有作者本人可以删除
+// mysql中只修改帖子状态，redis中的排序数据直接删除，列表中不再出现
+func DeletePost(ctx context.Context, userID, pid int64) (err error) {
+	ctx, span := tracing.Start(ctx, "logic.DeletePost")
+	defer func() { tracing.End(span, err) }()
+	post, err := mysql.GetPostById(ctx, pid)
+	if err != nil {
+		return
+	}
+	if post.AuthorID != userID {
+		return ErrorNoPermission
+	}
+	if err = mysql.DeletePost(ctx, pid); err != nil {
+		return
+	}
+	if err = cache.InvalidatePost(ctx, pid); err != nil {
+		return
+	}
+	return redis.DeletePost(ctx, pid, int64(post.CommunityID))
+}
+
+// GetPostRevisions 查询帖子的编辑历史
+func GetPostRevisions(ctx context.Context, pid int64) (_ []*models.PostRevision, err error) {
+	ctx, span := tracing.Start(ctx, "logic.GetPostRevisions")
+	defer func() { tracing.End(span, err) }()
+	if _, err := mysql.GetPostById(ctx, pid); err != nil {
+		return nil, err
+	}
+	return mysql.GetPostRevisions(ctx, pid)
+}
+
 // GetPostById 根据帖子id查询帖子详情数据
-func GetPostById(pid int64) (data *models.ApiPostDetail, err error) {
+func GetPostById(ctx context.Context, pid int64) (data *models.ApiPostDetail, err error) {
+	ctx, span := tracing.Start(ctx, "logic.GetPostById")
+	defer func() { tracing.End(span, err) }()
 	// 查询并组合我们接口想用的数据
-	post, err := mysql.GetPostById(pid)
+	post, err := cache.GetPostByID(ctx, pid)
 	if err != nil {
-		zap.L().Error("mysql.GetPostById(pid) failed",
+		logger.FromContext(ctx).Error("cache.GetPostByID(pid) failed",
 			zap.Int64("pid", pid),
 			zap.Error(err))
 		return
 	}
 	// 根据作者id查询作者信息
-	user, err := mysql.GetUserById(post.AuthorID)
+	user, err := cache.GetUserByID(ctx, post.AuthorID)
 	if err != nil {
-		zap.L().Error("mysql.GetUserById(post.AuthorID) failed",
+		logger.FromContext(ctx).Error("cache.GetUserByID(post.AuthorID) failed",
 			zap.Int64("author_id", post.AuthorID),
 			zap.Error(err))
 		return
 	}
 	// 根据社区id查询社区详细信息
-	community, err := mysql.GetCommunityDetailByID(post.CommunityID)
+	community, err := cache.GetCommunityDetailByID(ctx, int64(post.CommunityID))
 	if err != nil {
-		zap.L().Error("mysql.GetUserById(post.AuthorID) failed",
-			zap.Int64("community_id", post.CommunityID),
+		logger.FromContext(ctx).Error("cache.GetCommunityDetailByID(post.CommunityID) failed",
+			zap.Int64("community_id", int64(post.CommunityID)),
 			zap.Error(err))
 		return
 	}
@@ -57,158 +131,172 @@ func GetPostById(pid int64) (data *models.ApiPostDetail, err error) {
 }
 
 // GetPostList 获取帖子列表
-func GetPostList(page, size int64) (data []*models.ApiPostDetail, err error) {
-	posts, err := mysql.GetPostList(page, size)
+func GetPostList(ctx context.Context, page, size int64) (data []*models.ApiPostDetail, err error) {
+	ctx, span := tracing.Start(ctx, "logic.GetPostList")
+	defer func() { tracing.End(span, err) }()
+	posts, err := mysql.GetPostList(ctx, page, size)
 	if err != nil {
 		return nil, err
 	}
-	data = make([]*models.ApiPostDetail, 0, len(posts))
-
-	for _, post := range posts {
-		// 根据作者id查询作者信息
-		user, err := mysql.GetUserById(post.AuthorID)
-		if err != nil {
-			zap.L().Error("mysql.GetUserById(post.AuthorID) failed",
-				zap.Int64("author_id", post.AuthorID),
-				zap.Error(err))
-			continue
-		}
-		// 根据社区id拆线呢社区详细信息
-		community, err := mysql.GetCommunityDetailByID(post.CommunityID)
-		if err != nil {
-			zap.L().Error("mysql.GetUserById(post.AuthorID) failed",
-				zap.Int64("community_id", post.CommunityID),
-				zap.Error(err))
-			continue
-		}
-		postDetail := &models.ApiPostDetail{
-			AuthorName:      user.Username,
-			Post:            post,
-			CommunityDetail: community,
-		}
-		data = append(data, postDetail)
-	}
-	return
+	return assemblePostDetails(ctx, posts)
 }
 
-func GetPostList2(p *models.ParamPostList) (data []*models.ApiPostDetail, err error) {
+func GetPostList2(ctx context.Context, p *models.ParamPostList) (data []*models.ApiPostDetail, next string, err error) {
+	ctx, span := tracing.Start(ctx, "logic.GetPostList2")
+	defer func() { tracing.End(span, err) }()
 	// 去redis查询id列表
-	ids, err := redis.GetPostIDsInOrder(p)
+	ids, next, err := redis.GetPostIDsInOrder(ctx, p)
 	if err != nil {
 		return
 	}
 	if len(ids) == 0 {
-		zap.L().Warn("redis.GetPostIDsInOrder(p) return 0 data")
+		logger.FromContext(ctx).Warn("redis.GetPostIDsInOrder(p) return 0 data")
 		return
 	}
-	zap.L().Debug("GetPostList2", zap.Any("ids", ids))
+	logger.FromContext(ctx).Debug("GetPostList2", zap.Any("ids", ids))
 	// 根据id去mysql数据库查询帖子详情信息
-	posts, err := mysql.GetPostListByIDs(ids)
+	data, err = getPostDetailsByIDs(ctx, ids)
+	return
+}
+
+func GetCommunityPostList(ctx context.Context, p *models.ParamPostList) (data []*models.ApiPostDetail, next string, err error) {
+	ctx, span := tracing.Start(ctx, "logic.GetCommunityPostList")
+	defer func() { tracing.End(span, err) }()
+	//  去redis查询id列表
+	ids, next, err := redis.GetCommunityPostIDsInOrder(ctx, p)
 	if err != nil {
 		return
 	}
-	zap.L().Debug("GetPostList2", zap.Any("posts", posts))
-	// 提前查询好每篇帖子的投票数
-	voteData, err := redis.GetPostVoteData(ids)
-	if err != nil {
+	if len(ids) == 0 {
+		logger.FromContext(ctx).Warn("redis.GetPostIDsInOrder(p) return 0 data")
 		return
 	}
+	logger.FromContext(ctx).Debug("GetCommunityPostIDsInOrder", zap.Any("ids", ids))
+	//  根据id去MySQL数据库查询帖子详细信息
+	data, err = getPostDetailsByIDs(ctx, ids)
+	return
+}
 
-	// 将帖子的作者及分区信息查询出来填充到帖子中
-	for idx, post := range posts {
-		// 根据作者id查询作者信息
-		user, err := mysql.GetUserById(post.AuthorID)
-		if err != nil {
-			zap.L().Error("mysql.GetUserById(post.AuthorID) failed",
-				zap.Int64("author_id", post.AuthorID),
-				zap.Error(err))
-			continue
-		}
-		// 根据社区id查询社区详细信息
-		community, err := mysql.GetCommunityDetailByID(post.CommunityID)
-		if err != nil {
-			zap.L().Error("mysql.GetUserById(post.AuthorID) failed",
-				zap.Int64("community_id", post.CommunityID),
-				zap.Error(err))
-			continue
-		}
-		postDetail := &models.ApiPostDetail{
-			AuthorName:      user.Username,
-			VoteNum:         voteData[idx],
-			Post:            post,
-			CommunityDetail: community,
-		}
-		data = append(data, postDetail)
+// GetPostListNew  将两个查询帖子列表逻辑合二为一的函数
+// 游标分页时同时返回下一页的游标
+func GetPostListNew(ctx context.Context, p *models.ParamPostList) (data *models.ApiPostList, err error) {
+	ctx, span := tracing.Start(ctx, "logic.GetPostListNew")
+	defer func() { tracing.End(span, err) }()
+	data = new(models.ApiPostList)
+	// 根据请求参数的不同，执行不同的逻辑。
+	if p.CommunityID == 0 {
+		// 查所有
+		data.List, data.NextCursor, err = GetPostList2(ctx, p)
+	} else {
+		// 根据社区id查询
+		data.List, data.NextCursor, err = GetCommunityPostList(ctx, p)
+	}
+	if err != nil {
+		logger.FromContext(ctx).Error("GetPostListNew failed", zap.Error(err))
+		return nil, err
 	}
 	return
 }
 
-func GetCommunityPostList(p *models.ParamPostList) (data []*models.ApiPostDetail, err error) {
-	//  去redis查询id列表
-	ids, err := redis.GetCommunityPostIDsInOrder(p)
+// getPostDetailsByIDs 根据有序的帖子id查询帖子详情
+func getPostDetailsByIDs(ctx context.Context, ids []string) (data []*models.ApiPostDetail, err error) {
+	// 返回的数据还要按照我给定的id的顺序返回
+	pids := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		pid, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		pids = append(pids, pid)
+	}
+	postMap, err := cache.GetPostsByIDs(ctx, pids)
 	if err != nil {
 		return
 	}
-	if len(ids) == 0 {
-		zap.L().Warn("redis.GetPostIDsInOrder(p) return 0 data")
-		return
+	posts := make([]*models.Post, 0, len(pids))
+	for _, pid := range pids {
+		if post, ok := postMap[pid]; ok {
+			posts = append(posts, post)
+		}
 	}
-	zap.L().Debug("GetCommunityPostIDsInOrder", zap.Any("ids", ids))
-	//  根据id去MySQL数据库查询帖子详细信息
-	// 返回的数据还要按照我给定的id的顺序返回
-	posts, err := mysql.GetPostListByIDs(ids)
-	if err != nil {
+	return assemblePostDetails(ctx, posts)
+}
+
+// assemblePostDetails 为帖子列表填充作者、社区及投票数据，所有帖子列表接口共用
+// 作者和社区信息各用一条sql批量查询
+func assemblePostDetails(ctx context.Context, posts []*models.Post) (data []*models.ApiPostDetail, err error) {
+	data = make([]*models.ApiPostDetail, 0, len(posts))
+	if len(posts) == 0 {
 		return
 	}
-	zap.L().Debug("GetPostList2", zap.Any("posts", posts))
+	ids := make([]string, 0, len(posts))
+	for _, post := range posts {
+		ids = append(ids, strconv.FormatInt(post.ID, 10))
+	}
 	// 提前查询好每篇帖子的投票数
-	voteData, err := redis.GetPostVoteData(ids)
+	voteData, err := getPostVoteData(ctx, ids)
 	if err != nil {
 		return
 	}
+	loader := newPostLoader()
+	if err = loader.loadPosts(ctx, posts); err != nil {
+		return
+	}
 
-	// 将帖子的作者及分区信息查询出来填充到帖子中
+	// 将帖子的作者及分区信息填充到帖子中
 	for idx, post := range posts {
-		// 根据作者id查询作者信息
-		user, err := mysql.GetUserById(post.AuthorID)
-		if err != nil {
-			zap.L().Error("mysql.GetUserById(post.AuthorID) failed",
-				zap.Int64("author_id", post.AuthorID),
-				zap.Error(err))
+		user, ok := loader.user(post.AuthorID)
+		if !ok {
+			logger.FromContext(ctx).Error("author of post not found",
+				zap.Int64("post_id", post.ID),
+				zap.Int64("author_id", post.AuthorID))
 			continue
 		}
-		// 根据社区id查询社区详细信息
-		community, err := mysql.GetCommunityDetailByID(post.CommunityID)
-		if err != nil {
-			zap.L().Error("mysql.GetUserById(post.AuthorID) failed",
-				zap.Int64("community_id", post.CommunityID),
-				zap.Error(err))
+		community, ok := loader.community(int64(post.CommunityID))
+		if !ok {
+			logger.FromContext(ctx).Error("community of post not found",
+				zap.Int64("post_id", post.ID),
+				zap.Int64("community_id", int64(post.CommunityID)))
 			continue
 		}
-		postDetail := &models.ApiPostDetail{
+		data = append(data, &models.ApiPostDetail{
 			AuthorName:      user.Username,
 			VoteNum:         voteData[idx],
 			Post:            post,
 			CommunityDetail: community,
-		}
-		data = append(data, postDetail)
+		})
 	}
 	return
 }
 
-// GetPostListNew  将两个查询帖子列表逻辑合二为一的函数
-func GetPostListNew(p *models.ParamPostList) (data []*models.ApiPostDetail, err error) {
-	// 根据请求参数的不同，执行不同的逻辑。
-	if p.CommunityID == 0 {
-		// 查所有
-		data, err = GetPostList2(p)
-	} else {
-		// 根据社区id查询
-		data, err = GetCommunityPostList(p)
+// getPostVoteData 查询每篇帖子的赞成票数
+// 已过投票期的帖子在redis中的投票记录已被归档删除，从mysql中查询归档的数据
+func getPostVoteData(ctx context.Context, ids []string) (data []int64, err error) {
+	data, err = redis.GetPostVoteData(ctx, ids)
+	if err != nil {
+		return
+	}
+	var missing []string
+	for idx, v := range data {
+		if v == 0 {
+			missing = append(missing, ids[idx])
+		}
 	}
+	if len(missing) == 0 {
+		return
+	}
+	votes, err := mysql.GetPostVotesByIDs(ctx, missing)
 	if err != nil {
-		zap.L().Error("GetPostListNew failed", zap.Error(err))
-		return nil, err
+		return
+	}
+	upCount := make(map[string]int64, len(votes))
+	for _, v := range votes {
+		upCount[strconv.FormatInt(v.PostID, 10)] = v.UpCount
+	}
+	for idx, id := range ids {
+		if v, ok := upCount[id]; ok {
+			data[idx] = v
+		}
 	}
 	return
 }
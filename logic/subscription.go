@@ -0,0 +1,59 @@
+package logic
+
+import (
+	"bluebell/dao/cache"
+	"bluebell/dao/mysql"
+	"bluebell/dao/redis"
+	"bluebell/logger"
+	"bluebell/models"
+	"bluebell/pkg/tracing"
+	"context"
+)
+
+// Subscribe 订阅社区
+func Subscribe(ctx context.Context, userID, communityID int64) (err error) {
+	ctx, span := tracing.Start(ctx, "logic.Subscribe")
+	defer func() { tracing.End(span, err) }()
+	if _, err = cache.GetCommunityDetailByID(ctx, communityID); err != nil {
+		return
+	}
+	if err = mysql.Subscribe(ctx, userID, communityID); err != nil {
+		return
+	}
+	return redis.ClearFeedCache(ctx, userID)
+}
+
+// Unsubscribe 取消订阅社区
+func Unsubscribe(ctx context.Context, userID, communityID int64) (err error) {
+	ctx, span := tracing.Start(ctx, "logic.Unsubscribe")
+	defer func() { tracing.End(span, err) }()
+	if err = mysql.Unsubscribe(ctx, userID, communityID); err != nil {
+		return
+	}
+	return redis.ClearFeedCache(ctx, userID)
+}
+
+// GetFeed 获取用户订阅的所有社区的帖子，按时间或分数排序
+func GetFeed(ctx context.Context, userID int64, p *models.ParamPostList) (data *models.ApiPostList, err error) {
+	ctx, span := tracing.Start(ctx, "logic.GetFeed")
+	defer func() { tracing.End(span, err) }()
+	data = new(models.ApiPostList)
+	communityIDs, err := mysql.GetSubscribedCommunityIDs(ctx, userID)
+	if err != nil {
+		return
+	}
+	if len(communityIDs) == 0 {
+		return
+	}
+	ids, next, err := redis.GetFeedPostIDsInOrder(ctx, userID, communityIDs, p)
+	if err != nil {
+		return
+	}
+	if len(ids) == 0 {
+		logger.FromContext(ctx).Warn("redis.GetFeedPostIDsInOrder(p) return 0 data")
+		return
+	}
+	data.NextCursor = next
+	data.List, err = getPostDetailsByIDs(ctx, ids)
+	return
+}
@@ -2,41 +2,190 @@ package logic
 
 import (
 	"bluebell/dao/mysql"
+	"bluebell/dao/redis"
+	"bluebell/logger"
 	"bluebell/models"
 	"bluebell/pkg/jwt"
+	"bluebell/pkg/metrics"
 	"bluebell/pkg/snowflake"
+	"bluebell/pkg/tracing"
+	"context"
+	"errors"
+
+	"go.uber.org/zap"
 )
 
-func SignUp(p *models.ParamSignUp) (err error) {
+func SignUp(ctx context.Context, p *models.ParamSignUp) (err error) {
+	ctx, span := tracing.Start(ctx, "logic.SignUp")
+	defer func() { tracing.End(span, err) }()
+	defer func() {
+		metrics.Signups.WithLabelValues(signUpOutcome(err)).Inc()
+	}()
 	// 判断用户存不存在
-	if err := mysql.CheckUserExist(p.Username);err != nil {
+	if err := mysql.CheckUserExist(ctx, p.Username); err != nil {
 		return err
 	}
 	// 生成uid
 	userID := snowflake.GenID()
 	// 构造一个User示例
 	user := &models.User{
-		UserID: userID,
+		UserID:   userID,
 		Username: p.Username,
 		Password: p.Password,
 	}
-	return mysql.InsertUser(user)
+	return mysql.InsertUser(ctx, user)
 }
 
-func Login(p *models.ParamLogin) (user *models.User,err error) {
+// Login 登录，ip为客户端ip，用于防暴力破解
+// 用户名不存在和密码错误都返回mysql.ErrorInvalidPassword，不暴露用户名是否存在
+func Login(ctx context.Context, p *models.ParamLogin, ip string) (user *models.User, err error) {
+	ctx, span := tracing.Start(ctx, "logic.Login")
+	defer func() { tracing.End(span, err) }()
+	defer func() {
+		metrics.Logins.WithLabelValues(loginOutcome(err)).Inc()
+	}()
+	if err = checkLoginLock(ctx, p.Username, ip); err != nil {
+		return nil, err
+	}
 	user = &models.User{
 		Username: p.Username,
 		Password: p.Password,
 	}
 	// 传递的是指针，就能拿到user.UserId
-	if err := mysql.Login(user);err != nil {
-		return nil,err
+	if err := mysql.Login(ctx, user); err != nil {
+		if errors.Is(err, mysql.ErrorInvalidPassword) {
+			recordLoginFailure(ctx, p.Username, ip)
+		}
+		return nil, err
+	}
+	clearLoginFailure(ctx, p.Username)
+	if user.Status == models.UserStatusBanned {
+		return nil, ErrorUserBanned
 	}
 	// 生成JWT
-	token,err := jwt.GenToken(user.UserID,user.Username)
+	if err = issueToken(ctx, user); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// signUpOutcome 注册结果，用于统计
+func signUpOutcome(err error) string {
+	switch {
+	case err == nil:
+		return "success"
+	case errors.Is(err, mysql.ErrorUserExist):
+		return "user_exist"
+	default:
+		return "error"
+	}
+}
+
+// loginOutcome 登录结果，用于统计
+func loginOutcome(err error) string {
+	var locked *LoginLockedError
+	switch {
+	case err == nil:
+		return "success"
+	case errors.Is(err, mysql.ErrorInvalidPassword):
+		return "invalid_credentials"
+	case errors.As(err, &locked):
+		return "locked"
+	case errors.Is(err, ErrorUserBanned):
+		return "banned"
+	default:
+		return "error"
+	}
+}
+
+// RefreshToken 使用refresh token换取一对新的token
+// 每个refresh token只能使用一次，重复使用说明token可能已经泄漏，此时吊销该用户的所有token
+func RefreshToken(ctx context.Context, rToken string) (user *models.User, err error) {
+	ctx, span := tracing.Start(ctx, "logic.RefreshToken")
+	defer func() { tracing.End(span, err) }()
+	mc, err := jwt.ParseToken(rToken)
+	if err != nil || mc.Type != jwt.TokenTypeRefresh {
+		return nil, ErrorInvalidToken
+	}
+	ok, err := redis.ConsumeRefreshToken(ctx, mc.Id)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		logger.FromContext(ctx).Warn("refresh token reused, revoke all tokens of user",
+			zap.Int64("user_id", mc.UserID))
+		if err := redis.IncrTokenVersion(ctx, mc.UserID); err != nil {
+			return nil, err
+		}
+		return nil, ErrorInvalidToken
+	}
+	if err := checkTokenVersion(ctx, mc); err != nil {
+		return nil, err
+	}
+	// 重新查询用户，使角色和封禁状态的变化在刷新token时生效
+	user, err = mysql.GetUserById(ctx, mc.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Status == models.UserStatusBanned {
+		return nil, ErrorUserBanned
+	}
+	if err = issueToken(ctx, user); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// Logout 退出登录，使该用户此前签发的所有token失效
+func Logout(ctx context.Context, userID int64) (err error) {
+	ctx, span := tracing.Start(ctx, "logic.Logout")
+	defer func() { tracing.End(span, err) }()
+	return redis.IncrTokenVersion(ctx, userID)
+}
+
+// ParseAccessToken 解析并校验access token
+func ParseAccessToken(ctx context.Context, aToken string) (_ *jwt.MyClaims, err error) {
+	ctx, span := tracing.Start(ctx, "logic.ParseAccessToken")
+	defer func() { tracing.End(span, err) }()
+	mc, err := jwt.ParseToken(aToken)
+	if err != nil || mc.Type != jwt.TokenTypeAccess {
+		return nil, ErrorInvalidToken
+	}
+	if err := checkTokenVersion(ctx, mc); err != nil {
+		return nil, err
+	}
+	return mc, nil
+}
+
+// checkTokenVersion 判断token签发后用户是否退出过登录
+func checkTokenVersion(ctx context.Context, mc *jwt.MyClaims) error {
+	version, err := redis.GetTokenVersion(ctx, mc.UserID)
+	if err != nil {
+		return err
+	}
+	if mc.Version != version {
+		return ErrorInvalidToken
+	}
+	return nil
+}
+
+// issueToken 为用户签发access token和refresh token
+func issueToken(ctx context.Context, user *models.User) (err error) {
+	version, err := redis.GetTokenVersion(ctx, user.UserID)
+	if err != nil {
+		return
+	}
+	user.Token, err = jwt.GenAccessToken(user.UserID, user.Username, int8(user.Role), version)
 	if err != nil {
 		return
 	}
-	user.Token = token
+	rToken, rID, err := jwt.GenRefreshToken(user.UserID, user.Username, version)
+	if err != nil {
+		return
+	}
+	if err = redis.SaveRefreshToken(ctx, rID, user.UserID, jwt.RefreshTokenExpire()); err != nil {
+		return
+	}
+	user.RefreshToken = rToken
 	return
-}
\ No newline at end of file
+}
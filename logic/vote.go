@@ -2,17 +2,27 @@ package logic
 
 import (
 	"bluebell/dao/redis"
+	"bluebell/logger"
 	"bluebell/models"
+	"bluebell/pkg/metrics"
+	"bluebell/pkg/tracing"
+	"context"
 	"strconv"
 
 	"go.uber.org/zap"
 )
 
 // VoteForPost 为帖子投票的函数
-func VoteForPost(userID int64,p *models.ParamVoteData) error {
-	zap.L().Debug("VoteForPost",
+func VoteForPost(ctx context.Context, userID int64, p *models.ParamVoteData) (err error) {
+	ctx, span := tracing.Start(ctx, "logic.VoteForPost")
+	defer func() { tracing.End(span, err) }()
+	logger.FromContext(ctx).Debug("VoteForPost",
 		zap.Int64("userID", userID),
 		zap.String("postID", p.PostID),
 		zap.Int8("direction", p.Direction))
-	return redis.VoteForPost(strconv.Itoa(int(userID)), p.PostID, float64(p.Direction))
-}
\ No newline at end of file
+	if err := redis.VoteForPost(ctx, strconv.Itoa(int(userID)), p.PostID, float64(p.Direction)); err != nil {
+		return err
+	}
+	metrics.VotesCast.WithLabelValues("post", metrics.VoteDirection(p.Direction)).Inc()
+	return nil
+}
@@ -2,60 +2,188 @@ package main
 
 import (
 	"bluebell/controller"
+	"bluebell/dao/cache"
 	"bluebell/dao/mysql"
 	"bluebell/dao/redis"
 	"bluebell/logger"
+	"bluebell/logic"
+	"bluebell/pkg/i18n"
+	"bluebell/pkg/jwt"
+	"bluebell/pkg/metrics"
+	"bluebell/pkg/password"
 	"bluebell/pkg/snowflake"
+	"bluebell/pkg/tracing"
+	"bluebell/pkg/worker"
 	"bluebell/router"
 	"bluebell/setting"
+	"context"
+	"flag"
 	"fmt"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
 )
 
 func main() {
-	if len(os.Args) < 2 {
-		fmt.Println("need config file.eg: bluebell config.yaml")
+	// 服务启动失败时以非0状态退出，放在第一个defer里保证其余defer都已执行
+	var failed bool
+	defer func() {
+		if failed {
+			os.Exit(1)
+		}
+	}()
+	configFile := flag.String("config", "", "config file path, eg: ./conf/config.yaml")
+	overrides := make(setting.Overrides)
+	flag.Var(overrides, "set", "override a config key, eg: -set mysql.password=123 (repeatable)")
+	flag.Parse()
+	// 兼容 bluebell config.yaml 的用法
+	if *configFile == "" {
+		*configFile = flag.Arg(0)
+	}
+	if *configFile == "" {
+		fmt.Println("need config file.eg: bluebell -config config.yaml")
 		return
 	}
 	// 加载配置
-	if err := setting.Init(os.Args[1]); err != nil {
+	if err := setting.Init(*configFile, overrides); err != nil {
 		fmt.Printf("load config failed, err:%v\n", err)
 		return
 	}
-	if err := logger.Init(setting.Conf.LogConfig, setting.Conf.Mode); err != nil {
+	// 启动时使用的配置，可以热更新的配置项由各模块注册回调处理
+	cfg := setting.Get()
+	if err := logger.Init(cfg.LogConfig, cfg.Mode); err != nil {
 		fmt.Printf("init logger failed, err:%v\n", err)
 		return
 	}
+	defer zap.L().Sync() // 退出前把缓冲区的日志写入文件
 
-	if err := mysql.Init(setting.Conf.MySQLConfig); err != nil {
+	shutdownTracing, err := tracing.Init(cfg.TraceConfig, cfg.Name, cfg.Version)
+	if err != nil {
+		fmt.Printf("init tracing failed, err:%v\n", err)
+		return
+	}
+
+	if err := mysql.Init(cfg.MySQLConfig); err != nil {
 		fmt.Printf("init mysql failed, err:%v\n", err)
 		return
 	}
 	defer mysql.Close() // 程序退出关闭数据库连接
 
-	if err := redis.Init(setting.Conf.RedisConfig); err != nil {
+	if err := redis.Init(cfg.RedisConfig); err != nil {
 		fmt.Printf("init redis failed, err:%v\n", err)
 		return
 	}
 	defer redis.Close()
 
-	if err := snowflake.Init(setting.Conf.StartTime, setting.Conf.MachineID); err != nil {
+	cache.Init(cfg.CacheConfig)
+
+	if err := snowflake.Init(cfg.StartTime, cfg.MachineID); err != nil {
 		fmt.Printf("init snowflake failed, err:%v\n", err)
 		return
 	}
 
+	legacySalt, err := setting.ResolveSecret(cfg.LegacyMD5Salt)
+	if err != nil {
+		fmt.Printf("load legacy md5 salt failed, err:%v\n", err)
+		return
+	}
+	if err := password.Init(cfg.PasswordHasher, legacySalt); err != nil {
+		fmt.Printf("init password hasher failed, err:%v\n", err)
+		return
+	}
+
+	if err := jwt.Init(cfg.AuthConfig); err != nil {
+		fmt.Printf("init jwt keys failed, err:%v\n", err)
+		return
+	}
+
+	// 加载提示信息的消息文件
+	if err := i18n.Init(cfg.I18nConfig.Dir, cfg.DefaultLang); err != nil {
+		fmt.Printf("init i18n failed, err:%v\n", err)
+		return
+	}
 	// 初始化gin框架内置的校验器使用的翻译器
-	if err := controller.InitTrans("zh"); err != nil {
+	if err := controller.InitTrans(); err != nil {
 		fmt.Printf("init validator trans failed, err:%v\n", err)
 		return
 	}
 
+	// 启动后台任务，退出时按启动的相反顺序停止
+	workers := new(worker.Group)
+	// 定期归档已过投票期的投票数据
+	archiveCfg := cfg.ArchiveConfig
+	workers.Go("vote_archiver", func(ctx context.Context) {
+		logic.RunVoteArchiver(ctx, time.Duration(archiveCfg.Interval)*time.Minute, archiveCfg.BatchSize)
+	})
+	// 接收其他实例的缓存失效通知
+	workers.Go("cache_invalidation", cache.RunInvalidationListener)
+	logic.SetWorkersAlive(workers.Alive)
+
+	// 注册连接池及缓存的监控指标
+	metrics.RegisterDBStats(mysql.Stats)
+	metrics.RegisterRedisPoolStats(redis.PoolStats)
+	metrics.RegisterCacheStats(func() (int64, int64, int64) {
+		s := cache.GetStats()
+		return s.LocalHits, s.RedisHits, s.Misses
+	})
+
 	// 注册路由
-	r := router.SetupRouter(setting.Conf.Mode)
-	err := r.Run(fmt.Sprintf(":%d", setting.Conf.Port))
+	r, err := router.SetupRouter(cfg.Mode, cfg.TrustedProxies)
 	if err != nil {
-		fmt.Printf("run server failed, err:%v\n", err)
+		fmt.Printf("setup router failed, err:%v\n", err)
 		return
 	}
+	srv := &http.Server{
+		Addr:         fmt.Sprintf(":%d", cfg.Port),
+		Handler:      r,
+		ReadTimeout:  time.Duration(cfg.ReadTimeout) * time.Second,
+		WriteTimeout: time.Duration(cfg.WriteTimeout) * time.Second,
+		IdleTimeout:  time.Duration(cfg.IdleTimeout) * time.Second,
+	}
+	serveErr := make(chan error, 1)
+	go func() {
+		// 开启一个goroutine启动服务
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			serveErr <- err
+		}
+	}()
 
+	// 等待中断信号来优雅地关闭服务器
+	quit := make(chan os.Signal, 1)
+	// kill 默认会发送 syscall.SIGTERM 信号，Ctrl+C 触发 syscall.SIGINT 信号
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	select {
+	case <-quit:
+	case err := <-serveErr:
+		zap.L().Error("run server failed", zap.Error(err))
+		failed = true
+	}
+	zap.L().Info("shutdown server ...")
+	// 先让/readyz返回503，等负载均衡摘除本实例后再停止接受新请求
+	// 服务没有启动成功时不会有请求进来，不需要等待
+	logic.SetDraining()
+	if !failed {
+		time.Sleep(time.Duration(setting.Get().ShutdownDelay) * time.Second)
+	}
+
+	// 在shutdown_timeout内先把处理中的请求处理完，再停止后台任务
+	// 返回后再执行前面defer的关闭redis和mysql连接
+	ctx, cancel := context.WithTimeout(context.Background(),
+		time.Duration(setting.Get().ShutdownTimeout)*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		zap.L().Error("server shutdown failed", zap.Error(err))
+	}
+	if err := workers.Stop(ctx); err != nil {
+		zap.L().Error("stop workers failed", zap.Error(err))
+	}
+	// 导出缓冲中尚未发送的span
+	if err := shutdownTracing(ctx); err != nil {
+		zap.L().Error("shutdown tracing failed", zap.Error(err))
+	}
+	zap.L().Info("server exiting")
 }
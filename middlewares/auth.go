@@ -2,10 +2,14 @@ package middlewares
 
 import (
 	"bluebell/controller"
-	"bluebell/pkg/jwt"
+	"bluebell/logger"
+	"bluebell/logic"
+	"bluebell/models"
+	"errors"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 )
 
 // JWTAuthMiddleware 基于JWT的认证中间件
@@ -30,15 +34,24 @@ func JWTAuthMiddleware() func(c *gin.Context) {
 			return
 		}
 		// parts[1]是获取到的tokenString，我们使用之前定义好的解析JWT的函数来解析它
-		mc, err := jwt.ParseToken(parts[1])
+		// 同时校验token类型以及用户是否已退出登录
+		mc, err := logic.ParseAccessToken(c.Request.Context(), parts[1])
 		if err != nil {
-			controller.ResponseError(c, controller.CodeInvalidToken)
+			if errors.Is(err, logic.ErrorInvalidToken) {
+				controller.ResponseError(c, controller.CodeInvalidToken)
+			} else {
+				logger.FromContext(c.Request.Context()).Error("logic.ParseAccessToken failed", zap.Error(err))
+				controller.ResponseError(c, controller.CodeServerBusy)
+			}
 			c.Abort()
 			return
 		}
 		// 将当前请求的userID信息保存到请求的上下文c上
 		c.Set(controller.CtxUserIDKey, mc.UserID)
+		c.Set(controller.CtxUserRoleKey, models.Role(mc.Role))
+		// 之后的日志都带上user_id
+		c.Request = c.Request.WithContext(logger.With(c.Request.Context(), zap.Int64("user_id", mc.UserID)))
 
 		c.Next() // 后续的处理请求的函数中 可以用过c.Get(CtxUserIDKey) 来获取当前请求的用户信息
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,20 @@
+package middlewares
+
+import (
+	"bluebell/controller"
+	"bluebell/pkg/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LocaleMiddleware 选择返回提示信息使用的语言
+// 优先使用用户选择的语言(lang参数或cookie)，其次使用Accept-Language请求头
+func LocaleMiddleware() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		cookie, _ := c.Cookie("lang")
+		lang := i18n.Match(c.Query("lang"), cookie, c.GetHeader("Accept-Language"))
+		c.Set(controller.CtxLangKey, lang)
+		c.Header("Content-Language", lang)
+		c.Next()
+	}
+}
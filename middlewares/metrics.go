@@ -0,0 +1,42 @@
+package middlewares
+
+import (
+	"bluebell/pkg/metrics"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 标准的http方法，其他方法统一记为other，避免任意方法名导致指标的label无限增长
+var standardMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodPatch:   true,
+	http.MethodDelete:  true,
+	http.MethodConnect: true,
+	http.MethodOptions: true,
+	http.MethodTrace:   true,
+}
+
+// MetricsMiddleware 记录每个请求的耗时，未匹配到路由的请求统一记为unmatched，非标准方法记为other
+func MetricsMiddleware() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		method := c.Request.Method
+		if !standardMethods[method] {
+			method = "other"
+		}
+		metrics.HTTPRequestDuration.
+			WithLabelValues(method, route, strconv.Itoa(c.Writer.Status())).
+			Observe(time.Since(start).Seconds())
+	}
+}
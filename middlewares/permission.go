@@ -0,0 +1,21 @@
+package middlewares
+
+import (
+	"bluebell/controller"
+	"bluebell/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PermissionMiddleware 校验当前用户的角色是否拥有指定权限，需要在JWTAuthMiddleware之后使用
+// 版主的权限是否覆盖要操作的社区由具体的业务逻辑判断
+func PermissionMiddleware(perm models.Permission) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		if !controller.GetCurrentUserRole(c).Can(perm) {
+			controller.ResponseError(c, controller.CodeNoPermission)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
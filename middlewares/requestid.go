@@ -0,0 +1,38 @@
+package middlewares
+
+import (
+	"bluebell/controller"
+	"bluebell/logger"
+	"bluebell/pkg/tracing"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// HeaderRequestID 请求id的请求头和响应头
+const HeaderRequestID = "X-Request-ID"
+
+// 只接受长度有限的简单字符，避免伪造的请求头污染日志
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// RequestIDMiddleware 使用上游传入的X-Request-ID，没有或不合法时生成一个新的
+// 请求id写回响应头，并给请求的logger带上request_id和trace_id
+func RequestIDMiddleware() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		id := c.GetHeader(HeaderRequestID)
+		if !validRequestID.MatchString(id) {
+			id = uuid.NewString()
+		}
+		c.Set(controller.CtxRequestIDKey, id)
+		c.Header(HeaderRequestID, id)
+
+		fields := []zap.Field{zap.String("request_id", id)}
+		if traceID := tracing.TraceID(c.Request.Context()); traceID != "" {
+			fields = append(fields, zap.String("trace_id", traceID))
+		}
+		c.Request = c.Request.WithContext(logger.With(c.Request.Context(), fields...))
+		c.Next()
+	}
+}
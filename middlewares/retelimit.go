@@ -1,25 +1,97 @@
 package middlewares
 
 import (
+	"bluebell/controller"
+	"bluebell/dao/redis"
+	"bluebell/logger"
+	"bluebell/pkg/jwt"
+	"bluebell/setting"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/juju/ratelimit"
+	"go.uber.org/zap"
 )
 
-// 漏桶限流
-func RateLimitMiddleware(fillInterval time.Duration, cap int64) func(c *gin.Context) {
-	bucket := ratelimit.NewBucket(fillInterval, cap)
+// redis不可用时每个请求都会失败，日志最多每隔rateLimitWarnInterval记录一次
+const rateLimitWarnInterval = 10 * time.Second
+
+var lastRateLimitWarn atomic.Int64
+
+// RateLimitMiddleware 基于redis的GCRA限流，所有实例共享同一份计数
+// 登录用户按用户id限流，未登录按客户端ip限流，限流参数修改配置文件后立即生效
+func RateLimitMiddleware() func(c *gin.Context) {
 	return func(c *gin.Context) {
-		// 如果取不到令牌就返回响应
-		//if bucket.Take(1) > 0 {
-		if bucket.TakeAvailable(1) != 1 {
-			c.String(http.StatusOK, "rate limit...")
+		cfg := setting.Get().RateLimitConfig
+		if cfg == nil {
+			c.Next()
+			return
+		}
+		// v2和v1是同一组接口，共用v1的限流规则和计数
+		route := c.FullPath()
+		if strings.HasPrefix(route, "/api/v2/") {
+			route = "/api/v1/" + strings.TrimPrefix(route, "/api/v2/")
+		}
+		name, rule := matchRateLimitRule(cfg, c.Request.Method, route)
+		if rule.Rate <= 0 {
+			c.Next()
+			return
+		}
+		burst := rule.Burst
+		if burst <= 0 {
+			burst = rule.Rate
+		}
+		res, err := redis.AllowRate(c.Request.Context(), name+":"+rateLimitIdentity(c), rule.Rate, burst,
+			time.Duration(rule.Period)*time.Second)
+		if err != nil {
+			// redis不可用时放行，不影响正常请求
+			if now, last := time.Now().UnixNano(), lastRateLimitWarn.Load(); now-last >= int64(rateLimitWarnInterval) &&
+				lastRateLimitWarn.CompareAndSwap(last, now) {
+				logger.FromContext(c.Request.Context()).Warn("redis.AllowRate failed", zap.Error(err))
+			}
+			c.Next()
+			return
+		}
+		// Limit是配置的每个周期允许的请求数，不是突发容量
+		c.Header("X-RateLimit-Limit", strconv.FormatInt(rule.Rate, 10))
+		c.Header("X-RateLimit-Remaining", strconv.FormatInt(res.Remaining, 10))
+		c.Header("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(res.ResetAfter)))
+		if !res.Allowed {
+			c.Header("Retry-After", strconv.Itoa(ceilSeconds(res.RetryAfter)))
+			controller.ResponseErrorWithStatus(c, http.StatusTooManyRequests, controller.CodeTooManyRequests)
 			c.Abort()
 			return
 		}
-		// 取到令牌就放行
 		c.Next()
 	}
 }
+
+// matchRateLimitRule 查找请求对应的限流规则，没有单独配置的接口使用默认规则
+func matchRateLimitRule(cfg *setting.RateLimitConfig, method, path string) (string, setting.RateLimitRule) {
+	for _, r := range cfg.Routes {
+		if strings.EqualFold(r.Method, method) && r.Path == path {
+			return strings.ToUpper(r.Method) + r.Path, r
+		}
+	}
+	return "default", cfg.Default
+}
+
+// rateLimitIdentity 携带有效access token时使用用户id，否则使用客户端ip
+// 这里只校验签名，token是否已注销由JWTAuthMiddleware判断
+func rateLimitIdentity(c *gin.Context) string {
+	parts := strings.SplitN(c.Request.Header.Get("Authorization"), " ", 2)
+	if len(parts) == 2 && parts[0] == "Bearer" {
+		if mc, err := jwt.ParseToken(parts[1]); err == nil && mc.Type == jwt.TokenTypeAccess {
+			return "user:" + strconv.FormatInt(mc.UserID, 10)
+		}
+	}
+	return "ip:" + c.ClientIP()
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,15 @@
+package middlewares
+
+import (
+	"bluebell/controller"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIVersionMiddleware 记录请求的接口版本，controller根据版本决定返回错误时的http状态码
+func APIVersionMiddleware(version int) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		c.Set(controller.CtxAPIVersionKey, version)
+		c.Next()
+	}
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+type Comment struct {
+	ID         int64     `json:"id,string" db:"comment_id"`       // 评论id
+	PostID     int64     `json:"post_id,string" db:"post_id"`     // 所属帖子id
+	ParentID   int64     `json:"parent_id,string" db:"parent_id"` // 回复的评论id，0表示直接回复帖子
+	AuthorID   int64     `json:"author_id,string" db:"author_id"` // 作者id
+	Content    string    `json:"content" db:"content"`            // 评论内容
+	CreateTime time.Time `json:"create_time" db:"create_time"`    // 评论时间
+}
+
+// ApiCommentDetail 评论列表接口中的一条评论及其回复
+type ApiCommentDetail struct {
+	AuthorName string              `json:"author_name"` // 作者
+	VoteNum    int64               `json:"vote_num"`    // 赞成票数
+	ReplyNum   int                 `json:"reply_num"`   // 直接回复的数量，超过层数限制的回复不展开但仍然计数
+	*Comment                       // 嵌入评论结构体
+	Children   []*ApiCommentDetail `json:"children,omitempty"` // 回复
+}
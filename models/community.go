@@ -2,6 +2,12 @@ package models
 
 import "time"
 
+// 社区状态
+const (
+	CommunityStatusNormal   int8 = 1 // 正常
+	CommunityStatusArchived int8 = 2 // 已归档，只能查看不能再发帖
+)
+
 type Community struct {
 	ID   int64  `json:"id" db:"community_id"`
 	Name string `json:"name" db:"community_name"`
@@ -11,5 +17,7 @@ type CommunityDetail struct {
 	ID           int64     `json:"id" db:"community_id"`
 	Name         string    `json:"name" db:"community_name"`
 	Introduction string    `json:"introduction,omitempty" db:"introduction"`
+	OwnerID      int64     `json:"owner_id,string" db:"owner_id"`
+	Status       int8      `json:"status" db:"status"`
 	CreateTime   time.Time `json:"create_time" db:"create_time"`
 }
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"bytes"
+	"strconv"
+)
+
+// ID 请求参数中的id，兼容数字和字符串两种格式
+// /api/v1的客户端传数字，/api/v2的客户端使用返回的字符串id
+type ID int64
+
+func (id *ID) UnmarshalJSON(b []byte) error {
+	if bytes.Equal(b, []byte("null")) {
+		return nil
+	}
+	n, err := strconv.ParseInt(string(bytes.Trim(b, `"`)), 10, 64)
+	if err != nil {
+		return err
+	}
+	*id = ID(n)
+	return nil
+}
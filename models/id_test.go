@@ -0,0 +1,30 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestIDUnmarshal(t *testing.T) {
+	tests := []struct {
+		body    string
+		want    ID
+		wantErr bool
+	}{
+		{`{"community_id": 1}`, 1, false},
+		{`{"community_id": "1"}`, 1, false},
+		{`{"community_id": null}`, 0, false},
+		{`{"community_id": "abc"}`, 0, true},
+		{`{"community_id": 1.5}`, 0, true},
+	}
+	for _, tt := range tests {
+		var p Post
+		err := json.Unmarshal([]byte(tt.body), &p)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("%s: err:%v, wantErr %v", tt.body, err, tt.wantErr)
+		}
+		if err == nil && p.CommunityID != tt.want {
+			t.Fatalf("%s: got %d, want %d", tt.body, p.CommunityID, tt.want)
+		}
+	}
+}
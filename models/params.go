@@ -2,7 +2,7 @@ package models
 
 // 定义请求的参数结构体
 const (
-	OrderTime = "time"
+	OrderTime  = "time"
 	OrderScore = "score"
 )
 
@@ -19,6 +19,11 @@ type ParamLogin struct {
 	Password string `json:"password" binding:"required"`
 }
 
+// ParamRefreshToken 刷新token请求参数
+type ParamRefreshToken struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
 // ParamVoteData 投票数据
 type ParamVoteData struct {
 	// UserID 从请求中获取当前的用户
@@ -26,10 +31,50 @@ type ParamVoteData struct {
 	Direction int8   `json:"direction,string" binding:"oneof=1 0 -1" ` // 赞成票(1)还是反对票(-1)取消投票(0)
 }
 
+// ParamUpdatePost 编辑帖子请求参数
+type ParamUpdatePost struct {
+	Title   string `json:"title" binding:"required"`
+	Content string `json:"content" binding:"required"`
+}
+
+// ParamCommunity 创建及修改社区请求参数
+type ParamCommunity struct {
+	Name         string `json:"name" binding:"required,max=128"`
+	Introduction string `json:"introduction" binding:"required,max=256"`
+}
+
+// ParamModerator 指派社区版主请求参数
+type ParamModerator struct {
+	UserID int64 `json:"user_id,string" binding:"required"`
+}
+
+// ParamComment 发表评论请求参数
+type ParamComment struct {
+	ParentID int64  `json:"parent_id,string"`                    // 回复的评论id，可以为空
+	Content  string `json:"content" binding:"required,max=2048"` // 评论内容
+}
+
+// ParamCommentVoteData 评论投票数据
+type ParamCommentVoteData struct {
+	CommentID string `json:"comment_id" binding:"required"`           // 评论id
+	Direction int8   `json:"direction,string" binding:"oneof=1 0 -1"` // 赞成票(1)还是反对票(-1)取消投票(0)
+}
+
+// ParamCommentList 获取评论列表query string参数
+type ParamCommentList struct {
+	ParentID int64  `json:"parent_id" form:"parent_id"`                                    // 从哪条评论开始展开回复，可以为空
+	Depth    int    `json:"depth" form:"depth" binding:"min=1,max=10" example:"3"`         // 最多展开的层数
+	Page     int64  `json:"page" form:"page" binding:"min=1" example:"1"`                  // 第一层评论的页码
+	Size     int64  `json:"size" form:"size" binding:"min=1,max=100" example:"10"`         // 第一层评论每页数据量
+	Order    string `json:"order" form:"order" binding:"oneof=time score" example:"score"` // 排序依据
+}
+
 // ParamPostList 获取帖子列表query string参数
 type ParamPostList struct {
-	CommunityID int64  `json:"community_id" form:"community_id"`   // 可以为空
-	Page        int64  `json:"page" form:"page" example:"1"`       // 页码
-	Size        int64  `json:"size" form:"size" example:"10"`      // 每页数据量
-	Order       string `json:"order" form:"order" example:"score"` // 排序依据
+	CommunityID int64  `json:"community_id" form:"community_id"`                              // 可以为空
+	Page        int64  `json:"page" form:"page" binding:"min=1" example:"1"`                  // 页码
+	Size        int64  `json:"size" form:"size" binding:"min=1,max=100" example:"10"`         // 每页数据量
+	Order       string `json:"order" form:"order" binding:"oneof=time score" example:"score"` // 排序依据
+	Cursor      string `json:"cursor" form:"cursor"`                                          // 游标，第一页为空，之后传上一页返回的next_cursor
+	UseCursor   bool   `json:"-" form:"-"`                                                    // 请求中带有cursor参数时使用游标分页
 }
@@ -4,14 +4,22 @@ import "time"
 
 // 内存对齐概念
 
+// 帖子状态
+const (
+	PostStatusDeleted int32 = 0 // 已删除
+	PostStatusNormal  int32 = 1 // 正常
+	PostStatusLocked  int32 = 2 // 已锁定，不能再评论和编辑
+)
+
 type Post struct {
 	ID          int64     `json:"id,string" db:"post_id"`                            // 帖子id
 	AuthorID    int64     `json:"author_id" db:"author_id"`                          // 作者id
-	CommunityID int64     `json:"community_id" db:"community_id" binding:"required"` // 社区id
+	CommunityID ID        `json:"community_id" db:"community_id" binding:"required"` // 社区id
 	Status      int32     `json:"status" db:"status"`                                // 帖子状态
 	Title       string    `json:"title" db:"title" binding:"required"`               // 帖子标题
 	Content     string    `json:"content" db:"content" binding:"required"`           // 帖子内容
 	CreateTime  time.Time `json:"create_time" db:"create_time"`                      // 帖子创建时间
+	UpdateTime  time.Time `json:"update_time" db:"update_time"`                      // 帖子更新时间
 }
 
 // ApiPostDetail 帖子详情接口的结构体
@@ -21,3 +29,18 @@ type ApiPostDetail struct {
 	*Post                               // 嵌入帖子结构体
 	*CommunityDetail `json:"community"` // 嵌入社区信息
 }
+
+// ApiPostList 游标分页的帖子列表
+type ApiPostList struct {
+	List       []*ApiPostDetail `json:"list"`
+	NextCursor string           `json:"next_cursor"` // 为空表示没有下一页
+}
+
+// PostRevision 帖子的历史版本，每次编辑前保存一份
+type PostRevision struct {
+	PostID     int64     `json:"post_id,string" db:"post_id"`     // 帖子id
+	EditorID   int64     `json:"editor_id,string" db:"editor_id"` // 修改人id
+	Title      string    `json:"title" db:"title"`                // 修改前的标题
+	Content    string    `json:"content" db:"content"`            // 修改前的内容
+	CreateTime time.Time `json:"create_time" db:"create_time"`    // 修改时间
+}
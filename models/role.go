@@ -0,0 +1,38 @@
+package models
+
+// Role 用户角色
+type Role int8
+
+const (
+	RoleUser      Role = 0 // 普通用户
+	RoleModerator Role = 1 // 社区版主，只能管理被指派的社区
+	RoleAdmin     Role = 2 // 站点管理员
+)
+
+// Permission 需要特定角色才能执行的操作
+type Permission string
+
+const (
+	PermRemovePost      Permission = "post:remove"         // 删除他人的帖子
+	PermLockPost        Permission = "post:lock"           // 锁定帖子
+	PermBanUser         Permission = "user:ban"            // 封禁用户
+	PermManageModerator Permission = "community:moderator" // 指派社区版主
+	PermViewDebug       Permission = "debug:view"          // 查看缓存命中等内部状态
+)
+
+// rolePermissions 每种角色拥有的权限
+var rolePermissions = map[Role][]Permission{
+	RoleModerator: {PermRemovePost, PermLockPost},
+	RoleAdmin:     {PermRemovePost, PermLockPost, PermBanUser, PermManageModerator, PermViewDebug},
+}
+
+// Can 判断角色是否拥有某项权限
+// 版主的权限只在其管理的社区内有效，由logic层进一步校验
+func (r Role) Can(p Permission) bool {
+	for _, perm := range rolePermissions[r] {
+		if perm == p {
+			return true
+		}
+	}
+	return false
+}
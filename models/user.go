@@ -1,8 +1,17 @@
 package models
 
+// 用户状态
+const (
+	UserStatusNormal int8 = 0 // 正常
+	UserStatusBanned int8 = 1 // 已封禁
+)
+
 type User struct {
-	UserID   int64  `db:"user_id"`
-	Username string `db:"username"`
-	Password string `db:"password"`
-	Token    string
+	UserID       int64  `db:"user_id"`
+	Username     string `db:"username"`
+	Password     string `db:"password"`
+	Role         Role   `db:"role"`
+	Status       int8   `db:"status"`
+	Token        string
+	RefreshToken string
 }
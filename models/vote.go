@@ -0,0 +1,15 @@
+package models
+
+// PostVote 投票期结束后归档到mysql的帖子投票统计
+type PostVote struct {
+	PostID    int64 `json:"post_id,string" db:"post_id"`
+	UpCount   int64 `json:"up_count" db:"up_count"`
+	DownCount int64 `json:"down_count" db:"down_count"`
+}
+
+// PostVoteRecord 归档的用户投票记录
+type PostVoteRecord struct {
+	PostID    int64 `json:"post_id,string" db:"post_id"`
+	UserID    int64 `json:"user_id,string" db:"user_id"`
+	Direction int8  `json:"direction" db:"direction"`
+}
@@ -0,0 +1,92 @@
+package i18n
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/text/language"
+	"gopkg.in/yaml.v3"
+)
+
+// 消息文件放在同一个目录下，文件名就是语言，如 zh.yaml、en.yaml、zh-TW.yaml
+// 文件内容是 key: 消息 的映射，新增语言只需要放入对应的文件后重启
+
+var (
+	catalogs    map[string]map[string]string
+	langs       []string
+	defaultLang string
+	matcher     language.Matcher
+)
+
+// Init 加载dir下的所有消息文件，def是找不到匹配的语言或消息时使用的语言
+func Init(dir, def string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		return err
+	}
+	loaded := make(map[string]map[string]string, len(files))
+	names := make([]string, 0, len(files))
+	// 默认语言放在第一位，匹配不到时matcher返回第一个语言
+	tags := make([]language.Tag, 0, len(files))
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+		tag, err := language.Parse(name)
+		if err != nil {
+			return fmt.Errorf("invalid language of message file %s: %w", file, err)
+		}
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		msgs := make(map[string]string)
+		if err := yaml.Unmarshal(b, &msgs); err != nil {
+			return fmt.Errorf("parse message file %s failed: %w", file, err)
+		}
+		loaded[name] = msgs
+		if name == def {
+			names = append([]string{name}, names...)
+			tags = append([]language.Tag{tag}, tags...)
+		} else {
+			names = append(names, name)
+			tags = append(tags, tag)
+		}
+	}
+	if _, ok := loaded[def]; !ok {
+		return fmt.Errorf("message file of default language %q not found in %s", def, dir)
+	}
+	catalogs, langs, defaultLang, matcher = loaded, names, def, language.NewMatcher(tags)
+	return nil
+}
+
+// Default 默认语言
+func Default() string {
+	return defaultLang
+}
+
+// Languages 已加载的所有语言，第一个是默认语言
+func Languages() []string {
+	return langs
+}
+
+// Match 从用户偏好的语言中选出已加载的最合适的语言
+// prefs按优先级排列，每一项可以是语言名，也可以是Accept-Language请求头的值
+func Match(prefs ...string) string {
+	if matcher == nil {
+		return defaultLang
+	}
+	_, idx := language.MatchStrings(matcher, prefs...)
+	return langs[idx]
+}
+
+// T 获取指定语言的消息，该语言没有这条消息时使用默认语言，都没有时返回key
+func T(lang, key string) string {
+	if msg, ok := catalogs[lang][key]; ok {
+		return msg
+	}
+	if msg, ok := catalogs[defaultLang][key]; ok {
+		return msg
+	}
+	return key
+}
@@ -0,0 +1,37 @@
+package i18n
+
+import "testing"
+
+func TestMatchAndTranslate(t *testing.T) {
+	if err := Init("../../conf/i18n", "zh"); err != nil {
+		t.Fatalf("Init failed, err:%v", err)
+	}
+	if Languages()[0] != "zh" {
+		t.Errorf("default language should be first, got %v", Languages())
+	}
+	for _, c := range []struct {
+		prefs []string
+		want  string
+	}{
+		{[]string{"", "", "en-US,en;q=0.9"}, "en"},
+		{[]string{"", "", "zh-CN,zh;q=0.9,en;q=0.8"}, "zh"},
+		{[]string{"", "", "zh-Hant-TW"}, "zh-TW"},
+		{[]string{"en", "", "zh-CN"}, "en"},
+		{[]string{"", "", "de-DE"}, "zh"},
+		{[]string{"", "", "ja-JP,ja;q=0.9"}, "ja"},
+		{nil, "zh"},
+	} {
+		if got := Match(c.prefs...); got != c.want {
+			t.Errorf("Match(%q) = %q, want %q", c.prefs, got, c.want)
+		}
+	}
+	if got := T("en", "server_busy"); got != "Server is busy" {
+		t.Errorf("T(en) = %q", got)
+	}
+	if got := T("de", "server_busy"); got != T("zh", "server_busy") {
+		t.Errorf("unknown language should fall back to default, got %q", got)
+	}
+	if got := T("en", "no_such_key"); got != "no_such_key" {
+		t.Errorf("missing key should return key, got %q", got)
+	}
+}
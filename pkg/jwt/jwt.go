@@ -2,13 +2,22 @@ package jwt
 
 import (
 	"errors"
+	"strconv"
 	"time"
 
+	"bluebell/pkg/snowflake"
+	"bluebell/setting"
+
 	"github.com/dgrijalva/jwt-go"
-	"github.com/spf13/viper"
 )
 
-var mySecret = []byte("cghcccccggggghhhh")
+// token类型
+const (
+	TokenTypeAccess  = "access"  // 访问接口使用的短期token
+	TokenTypeRefresh = "refresh" // 用于换取新token的长期token
+)
+
+var ErrorInvalidToken = errors.New("invalid token")
 
 // MyClaims 自定义声明结构体并内嵌jwt.StandardClaims
 // jwt包自带的jwt.StandardClaims只包含了官方字段
@@ -17,35 +26,65 @@ var mySecret = []byte("cghcccccggggghhhh")
 type MyClaims struct {
 	UserID   int64  `json:"user_id"`
 	Username string `json:"username"`
+	Role     int8   `json:"role"` // 用户角色，只在access token中使用
+	Type     string `json:"typ"`  // token类型 access/refresh
+	Version  int64  `json:"ver"`  // 签发时用户的token版本号，用户登出后版本号递增，旧token随之失效
 
 	jwt.StandardClaims
 }
 
-// GenToken 生成jwt
-func GenToken(userID int64, username string) (string, error) {
+// AccessTokenExpire access token的有效期，修改配置文件后新签发的token立即生效
+func AccessTokenExpire() time.Duration {
+	return time.Duration(setting.Get().AccessTokenExpire) * time.Minute
+}
+
+// RefreshTokenExpire refresh token的有效期
+func RefreshTokenExpire() time.Duration {
+	return time.Duration(setting.Get().RefreshTokenExpire) * time.Hour
+}
+
+// GenAccessToken 生成access token
+func GenAccessToken(userID int64, username string, role int8, version int64) (string, error) {
 	// 创建一个我们自己的声明的数据
 	c := MyClaims{
-		userID,
-		"username", // 自定义字段
-		jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(
-				time.Duration(viper.GetInt("auth.jwt_expire")) * time.Hour).Unix(), // token过期时间
-			Issuer: "bluebell", // 签发人
+		UserID:   userID,
+		Username: username,
+		Role:     role,
+		Type:     TokenTypeAccess,
+		Version:  version,
+		StandardClaims: jwt.StandardClaims{
+			ExpiresAt: time.Now().Add(AccessTokenExpire()).Unix(), // token过期时间
+			Issuer:    "bluebell",                                 // 签发人
+		},
+	}
+	// 使用当前的key签名并获取完整的变化后的字符串token
+	return sign(c)
+}
+
+// GenRefreshToken 生成refresh token，同时返回token的唯一id(jti)
+// 服务端据此记录token是否已被使用，实现refresh token的轮换
+func GenRefreshToken(userID int64, username string, version int64) (token, id string, err error) {
+	id = strconv.FormatInt(snowflake.GenID(), 10)
+	c := MyClaims{
+		UserID:   userID,
+		Username: username,
+		Type:     TokenTypeRefresh,
+		Version:  version,
+		StandardClaims: jwt.StandardClaims{
+			Id:        id,
+			ExpiresAt: time.Now().Add(RefreshTokenExpire()).Unix(),
+			Issuer:    "bluebell",
 		},
 	}
-	// 使用指定的签名方法创建签名对象
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, c)
-	// 使用指定的secret签名并获取完整的变化后的字符串token
-	return token.SignedString(mySecret)
+	token, err = sign(c)
+	return
 }
 
-// ParseToken 解析JWT
+// ParseToken 解析JWT，根据header中的kid选择校验使用的key
 func ParseToken(tokenString string) (*MyClaims, error) {
 	// 解析token
 	var mc = new(MyClaims)
-	token, err := jwt.ParseWithClaims(tokenString, mc, func(token *jwt.Token) (i interface{}, err error) {
-		return mySecret, nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, mc, lookupKey)
 	if err != nil {
 		return nil, err
 	}
@@ -53,5 +92,5 @@ func ParseToken(tokenString string) (*MyClaims, error) {
 		// 校验token
 		return mc, nil
 	}
-	return nil, errors.New("invalid token")
+	return nil, ErrorInvalidToken
 }
@@ -0,0 +1,186 @@
+package jwt
+
+import (
+	"bluebell/setting"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// writeKeys 生成测试用的RSA和Ed25519私钥文件
+func writeKeys(t *testing.T) (rsaPath, edPath string) {
+	dir := t.TempDir()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaPath = filepath.Join(dir, "rsa.pem")
+	writePEM(t, rsaPath, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey))
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(edKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edPath = filepath.Join(dir, "ed25519.pem")
+	writePEM(t, edPath, "PRIVATE KEY", der)
+	return
+}
+
+func writePEM(t *testing.T, path, typ string, der []byte) {
+	data := pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der})
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func setupKeys(t *testing.T) *setting.AuthConfig {
+	rsaPath, edPath := writeKeys(t)
+	// token有效期等配置使用开发环境的配置文件
+	if err := setting.Init("../../conf/dev.yml", nil); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &setting.AuthConfig{
+		AccessTokenExpire:  30,
+		RefreshTokenExpire: 168,
+		JWTActiveKey:       "ed",
+		JWTKeys: []setting.JWTKey{
+			{ID: "hs", Secret: "test-secret"},
+			{ID: "rsa", Alg: AlgRS256, PrivateKey: "file:" + rsaPath},
+			{ID: "ed", Alg: AlgEdDSA, PrivateKey: "file:" + edPath},
+		},
+	}
+	if err := Init(cfg); err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+func TestSignWithEachAlg(t *testing.T) {
+	cfg := setupKeys(t)
+	for _, kid := range []string{"hs", "rsa", "ed"} {
+		cfg.JWTActiveKey = kid
+		ks, err := loadKeys(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys.Store(ks)
+		token, err := GenAccessToken(1, "alice", 0, 0)
+		if err != nil {
+			t.Fatalf("%s: %v", kid, err)
+		}
+		mc, err := ParseToken(token)
+		if err != nil {
+			t.Fatalf("%s: %v", kid, err)
+		}
+		if mc.UserID != 1 {
+			t.Fatalf("%s: user id = %d", kid, mc.UserID)
+		}
+	}
+	// HS256的密钥不能公开
+	jwks := JWKS()
+	if len(jwks) != 2 {
+		t.Fatalf("jwks has %d keys, want 2", len(jwks))
+	}
+}
+
+func TestRetiredKeyAcceptedUntilExpire(t *testing.T) {
+	cfg := setupKeys(t)
+	token, err := GenAccessToken(1, "alice", 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 切换到rsa并在配置中停用ed，到期之前ed签发的token仍然有效
+	rotated := *cfg
+	rotated.JWTActiveKey = "rsa"
+	rotated.JWTKeys = append([]setting.JWTKey(nil), cfg.JWTKeys...)
+	rotated.JWTKeys[2].RetireAt = time.Now().Add(time.Hour)
+	// 重启或其他实例加载同样的配置，结果相同
+	if _, err := loadKeys(&rotated); err != nil {
+		t.Fatal(err)
+	}
+	if err := reload(&rotated); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseToken(token); err != nil {
+		t.Fatalf("retired key rejected: %v", err)
+	}
+	var published bool
+	for _, k := range JWKS() {
+		published = published || k.Kid == "ed"
+	}
+	if !published {
+		t.Fatal("retired key should still be published")
+	}
+	// 停用的key不能用于签发
+	rotated.JWTActiveKey = "ed"
+	if _, err := loadKeys(&rotated); err == nil {
+		t.Fatal("retired key used as active key")
+	}
+
+	// 到期之后不再接受
+	rotated.JWTActiveKey = "rsa"
+	rotated.JWTKeys[2].RetireAt = time.Now().Add(-time.Second)
+	if err := reload(&rotated); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseToken(token); err == nil {
+		t.Fatal("expired key accepted")
+	}
+	for _, k := range JWKS() {
+		if k.Kid == "ed" {
+			t.Fatal("expired key should not be published")
+		}
+	}
+}
+
+// 支持多个key之前签发的token没有kid，只有配置了jwt_legacy_kid时才接受
+func TestTokenWithoutKid(t *testing.T) {
+	cfg := setupKeys(t)
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, MyClaims{
+		UserID: 1,
+		Type:   TokenTypeAccess,
+		StandardClaims: jwt.StandardClaims{
+			ExpiresAt: time.Now().Add(time.Hour).Unix(),
+		},
+	}).SignedString([]byte("test-secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseToken(token); err == nil {
+		t.Fatal("token without kid accepted")
+	}
+
+	cfg.JWTLegacyKey = "hs"
+	if err := reload(cfg); err != nil {
+		t.Fatal(err)
+	}
+	mc, err := ParseToken(token)
+	if err != nil {
+		t.Fatalf("legacy token rejected: %v", err)
+	}
+	if mc.UserID != 1 {
+		t.Fatalf("user id = %d", mc.UserID)
+	}
+
+	// 算法不一致时仍然拒绝
+	cfg.JWTLegacyKey = "rsa"
+	if err := reload(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseToken(token); err == nil {
+		t.Fatal("legacy token accepted with mismatched alg")
+	}
+}
@@ -0,0 +1,219 @@
+package jwt
+
+import (
+	"bluebell/setting"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync/atomic"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"go.uber.org/zap"
+)
+
+// 支持的签名算法，HS256的密钥只有bluebell自己持有，
+// RS256/EdDSA的公钥通过/.well-known/jwks.json公开，其他服务可以自行校验token
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+// key 一个签名key
+type key struct {
+	id        string
+	method    jwt.SigningMethod
+	signKey   interface{}      // 签名使用的key
+	verifyKey interface{}      // 校验使用的key
+	public    crypto.PublicKey // 非对称算法的公钥，对外公开
+	retireAt  time.Time        // 已停用的key，到期后不再接受
+}
+
+// keySet 当前可用的签名key
+type keySet struct {
+	activeID string          // 签发新token使用的key
+	legacyID string          // 没有kid的token使用的key，为空时不接受没有kid的token
+	keys     map[string]*key // kid -> key，校验token时使用
+}
+
+var keys atomic.Pointer[keySet]
+
+// Init 加载签名key，配置修改后重新加载，用于不重启轮换key
+func Init(cfg *setting.AuthConfig) error {
+	ks, err := loadKeys(cfg)
+	if err != nil {
+		return err
+	}
+	keys.Store(ks)
+	setting.OnChange(func(oldCfg, newCfg *setting.AppConfig) {
+		if newCfg.AuthConfig == nil {
+			return
+		}
+		if err := reload(newCfg.AuthConfig); err != nil {
+			zap.L().Error("reload jwt keys failed", zap.Error(err))
+		}
+	})
+	return nil
+}
+
+// reload 重新加载key
+// 停用的key由配置中的retire_at决定，重启或其他实例加载同样的配置时结果一致
+func reload(cfg *setting.AuthConfig) error {
+	ks, err := loadKeys(cfg)
+	if err != nil {
+		return err
+	}
+	keys.Store(ks)
+	return nil
+}
+
+func loadKeys(cfg *setting.AuthConfig) (*keySet, error) {
+	ks := &keySet{
+		activeID: cfg.JWTActiveKey,
+		legacyID: cfg.JWTLegacyKey,
+		keys:     make(map[string]*key, len(cfg.JWTKeys)),
+	}
+	for _, kc := range cfg.JWTKeys {
+		k, err := loadKey(kc)
+		if err != nil {
+			return nil, fmt.Errorf("load jwt key %s failed: %w", kc.ID, err)
+		}
+		if !k.retireAt.IsZero() && time.Now().After(k.retireAt) {
+			continue
+		}
+		ks.keys[kc.ID] = k
+	}
+	if k, ok := ks.keys[ks.activeID]; !ok || !k.retireAt.IsZero() {
+		return nil, fmt.Errorf("active jwt key %s not found or retired", ks.activeID)
+	}
+	return ks, nil
+}
+
+func loadKey(kc setting.JWTKey) (*key, error) {
+	k := &key{id: kc.ID, retireAt: kc.RetireAt}
+	switch kc.Alg {
+	case "", AlgHS256:
+		secret, err := setting.ResolveSecret(kc.Secret)
+		if err != nil {
+			return nil, err
+		}
+		k.method = jwt.SigningMethodHS256
+		k.signKey, k.verifyKey = []byte(secret), []byte(secret)
+	case AlgRS256:
+		pemData, err := setting.ResolveSecret(kc.PrivateKey)
+		if err != nil {
+			return nil, err
+		}
+		priv, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(pemData))
+		if err != nil {
+			return nil, err
+		}
+		k.method = jwt.SigningMethodRS256
+		k.signKey, k.verifyKey, k.public = priv, &priv.PublicKey, &priv.PublicKey
+	case AlgEdDSA:
+		pemData, err := setting.ResolveSecret(kc.PrivateKey)
+		if err != nil {
+			return nil, err
+		}
+		priv, err := parseEd25519PrivateKey([]byte(pemData))
+		if err != nil {
+			return nil, err
+		}
+		pub := priv.Public().(ed25519.PublicKey)
+		k.method = SigningMethodEdDSA
+		k.signKey, k.verifyKey, k.public = priv, pub, pub
+	default:
+		return nil, fmt.Errorf("unsupported alg %s", kc.Alg)
+	}
+	return k, nil
+}
+
+// parseEd25519PrivateKey 解析PKCS#8格式的PEM私钥，即 openssl genpkey -algorithm ed25519 生成的文件
+func parseEd25519PrivateKey(data []byte) (ed25519.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, jwt.ErrKeyMustBePEMEncoded
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	priv, ok := parsed.(ed25519.PrivateKey)
+	if !ok {
+		return nil, errors.New("key is not a valid Ed25519 private key")
+	}
+	return priv, nil
+}
+
+// lookupKey 根据token的header选择校验使用的key
+// token声明的算法必须与key的算法一致，防止用公钥冒充HMAC密钥
+func lookupKey(token *jwt.Token) (interface{}, error) {
+	ks := keys.Load()
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		kid = ks.legacyID
+	}
+	k, ok := ks.keys[kid]
+	if !ok || token.Method.Alg() != k.method.Alg() {
+		return nil, ErrorInvalidToken
+	}
+	if !k.retireAt.IsZero() && time.Now().After(k.retireAt) {
+		return nil, ErrorInvalidToken
+	}
+	return k.verifyKey, nil
+}
+
+// sign 使用当前的key签名，并在header中写入kid
+func sign(c MyClaims) (string, error) {
+	ks := keys.Load()
+	k := ks.keys[ks.activeID]
+	token := jwt.NewWithClaims(k.method, c)
+	token.Header["kid"] = k.id
+	return token.SignedString(k.signKey)
+}
+
+// JWK 公钥的JSON Web Key表示
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`   // RSA
+	E   string `json:"e,omitempty"`   // RSA
+	Crv string `json:"crv,omitempty"` // Ed25519
+	X   string `json:"x,omitempty"`   // Ed25519
+}
+
+// JWKS 当前接受的所有非对称key的公钥，包括尚未到期的已停用key
+func JWKS() []JWK {
+	ks := keys.Load()
+	now := time.Now()
+	jwks := make([]JWK, 0, len(ks.keys))
+	for _, k := range ks.keys {
+		if !k.retireAt.IsZero() && now.After(k.retireAt) {
+			continue
+		}
+		b64 := base64.RawURLEncoding.EncodeToString
+		switch pub := k.public.(type) {
+		case *rsa.PublicKey:
+			jwks = append(jwks, JWK{
+				Kty: "RSA", Kid: k.id, Use: "sig", Alg: AlgRS256,
+				N: b64(pub.N.Bytes()),
+				E: b64(big.NewInt(int64(pub.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			jwks = append(jwks, JWK{
+				Kty: "OKP", Kid: k.id, Use: "sig", Alg: AlgEdDSA,
+				Crv: "Ed25519", X: b64(pub),
+			})
+		}
+	}
+	return jwks
+}
@@ -0,0 +1,82 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "bluebell"
+
+// registry 只包含本项目注册的指标以及go运行时和进程的指标
+var registry = prometheus.NewRegistry()
+
+var (
+	// HTTPRequestDuration 请求耗时，route使用gin注册的路由，避免路径参数导致标签过多
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by route and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	// PostsCreated 发帖数
+	PostsCreated = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "posts_created_total",
+		Help:      "Number of posts created.",
+	})
+
+	// VotesCast 投票数，target为post/comment，direction为up/down/cancel
+	VotesCast = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "votes_cast_total",
+		Help:      "Number of accepted votes by target and direction.",
+	}, []string{"target", "direction"})
+
+	// Signups 注册次数，按结果区分
+	Signups = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "signups_total",
+		Help:      "Number of signup attempts by outcome.",
+	}, []string{"outcome"})
+
+	// Logins 登录次数，按结果区分
+	Logins = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "logins_total",
+		Help:      "Number of login attempts by outcome.",
+	}, []string{"outcome"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequestDuration,
+		PostsCreated,
+		VotesCast,
+		Signups,
+		Logins,
+	)
+}
+
+// Handler 输出所有指标的http handler
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// VoteDirection 把投票的值转换成direction标签
+func VoteDirection(value int8) string {
+	switch {
+	case value > 0:
+		return "up"
+	case value < 0:
+		return "down"
+	default:
+		return "cancel"
+	}
+}
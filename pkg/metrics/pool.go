@@ -0,0 +1,92 @@
+package metrics
+
+import (
+	"database/sql"
+
+	"github.com/go-redis/redis"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// RegisterDBStats 注册mysql连接池的指标，每次采集时调用fn获取最新数据
+func RegisterDBStats(fn func() sql.DBStats) {
+	gauge := func(name, help string, get func(s sql.DBStats) float64) prometheus.Collector {
+		return prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace, Subsystem: "mysql_pool", Name: name, Help: help,
+		}, func() float64 { return get(fn()) })
+	}
+	counter := func(name, help string, get func(s sql.DBStats) float64) prometheus.Collector {
+		return prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace, Subsystem: "mysql_pool", Name: name, Help: help,
+		}, func() float64 { return get(fn()) })
+	}
+	registry.MustRegister(
+		gauge("max_open_connections", "Maximum number of open connections.",
+			func(s sql.DBStats) float64 { return float64(s.MaxOpenConnections) }),
+		gauge("open_connections", "Number of established connections.",
+			func(s sql.DBStats) float64 { return float64(s.OpenConnections) }),
+		gauge("in_use_connections", "Number of connections currently in use.",
+			func(s sql.DBStats) float64 { return float64(s.InUse) }),
+		gauge("idle_connections", "Number of idle connections.",
+			func(s sql.DBStats) float64 { return float64(s.Idle) }),
+		counter("wait_count_total", "Total number of connections waited for.",
+			func(s sql.DBStats) float64 { return float64(s.WaitCount) }),
+		counter("wait_duration_seconds_total", "Total time blocked waiting for a new connection.",
+			func(s sql.DBStats) float64 { return s.WaitDuration.Seconds() }),
+		counter("max_idle_closed_total", "Total number of connections closed due to max idle.",
+			func(s sql.DBStats) float64 { return float64(s.MaxIdleClosed) }),
+		counter("max_lifetime_closed_total", "Total number of connections closed due to max lifetime.",
+			func(s sql.DBStats) float64 { return float64(s.MaxLifetimeClosed) }),
+	)
+}
+
+// RegisterRedisPoolStats 注册redis连接池的指标
+// 连接池参数热更新时会替换客户端，此时累计值会归零
+func RegisterRedisPoolStats(fn func() *redis.PoolStats) {
+	gauge := func(name, help string, get func(s *redis.PoolStats) float64) prometheus.Collector {
+		return prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace, Subsystem: "redis_pool", Name: name, Help: help,
+		}, func() float64 { return get(fn()) })
+	}
+	counter := func(name, help string, get func(s *redis.PoolStats) float64) prometheus.Collector {
+		return prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace, Subsystem: "redis_pool", Name: name, Help: help,
+		}, func() float64 { return get(fn()) })
+	}
+	registry.MustRegister(
+		gauge("total_connections", "Number of connections in the pool.",
+			func(s *redis.PoolStats) float64 { return float64(s.TotalConns) }),
+		gauge("idle_connections", "Number of idle connections in the pool.",
+			func(s *redis.PoolStats) float64 { return float64(s.IdleConns) }),
+		counter("hits_total", "Number of times a free connection was found in the pool.",
+			func(s *redis.PoolStats) float64 { return float64(s.Hits) }),
+		counter("misses_total", "Number of times a free connection was not found in the pool.",
+			func(s *redis.PoolStats) float64 { return float64(s.Misses) }),
+		counter("timeouts_total", "Number of times a wait timeout occurred.",
+			func(s *redis.PoolStats) float64 { return float64(s.Timeouts) }),
+		counter("stale_connections_total", "Number of stale connections removed from the pool.",
+			func(s *redis.PoolStats) float64 { return float64(s.StaleConns) }),
+	)
+}
+
+// RegisterCacheStats 注册读缓存的命中指标，fn返回本地命中、redis命中及未命中的累计次数
+func RegisterCacheStats(fn func() (localHits, redisHits, misses int64)) {
+	desc := prometheus.NewDesc(prometheus.BuildFQName(namespace, "cache", "requests_total"),
+		"Number of cache lookups by result.", []string{"result"}, nil)
+	registry.MustRegister(&cacheCollector{desc: desc, fn: fn})
+}
+
+type cacheCollector struct {
+	desc *prometheus.Desc
+	fn   func() (localHits, redisHits, misses int64)
+}
+
+func (c *cacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *cacheCollector) Collect(ch chan<- prometheus.Metric) {
+	localHits, redisHits, misses := c.fn()
+	ch <- prometheus.MustNewConstMetric(c.desc, prometheus.CounterValue, float64(localHits), "local_hit")
+	ch <- prometheus.MustNewConstMetric(c.desc, prometheus.CounterValue, float64(redisHits), "redis_hit")
+	ch <- prometheus.MustNewConstMetric(c.desc, prometheus.CounterValue, float64(misses), "miss")
+}
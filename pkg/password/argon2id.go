@@ -0,0 +1,96 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+const argon2idPrefix = "$argon2id$"
+
+// Argon2id argon2id算法，哈希格式为
+// $argon2id$v=19$m=19456,t=2,p=1$<salt>$<hash>
+type Argon2id struct {
+	Memory  uint32 // KiB
+	Time    uint32
+	Threads uint8
+	SaltLen uint32
+	KeyLen  uint32
+}
+
+// NewArgon2id 使用OWASP推荐的参数
+func NewArgon2id() *Argon2id {
+	return &Argon2id{
+		Memory:  19 * 1024,
+		Time:    2,
+		Threads: 1,
+		SaltLen: 16,
+		KeyLen:  32,
+	}
+}
+
+func (a *Argon2id) Name() string {
+	return "argon2id"
+}
+
+func (a *Argon2id) Hash(password string) (string, error) {
+	salt := make([]byte, a.SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, a.Time, a.Memory, a.Threads, a.KeyLen)
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2idPrefix, argon2.Version, a.Memory, a.Time, a.Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func (a *Argon2id) Match(encoded string) bool {
+	return strings.HasPrefix(encoded, argon2idPrefix)
+}
+
+func (a *Argon2id) Verify(password, encoded string) (bool, error) {
+	p, salt, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		return false, err
+	}
+	other := argon2.IDKey([]byte(password), salt, p.Time, p.Memory, p.Threads, uint32(len(key)))
+	return subtle.ConstantTimeCompare(key, other) == 1, nil
+}
+
+func (a *Argon2id) NeedsRehash(encoded string) bool {
+	p, salt, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		return true
+	}
+	return p.Memory != a.Memory || p.Time != a.Time || p.Threads != a.Threads ||
+		uint32(len(salt)) != a.SaltLen || uint32(len(key)) != a.KeyLen
+}
+
+// decodeArgon2id 解析哈希字符串中的参数、盐值和哈希值
+func decodeArgon2id(encoded string) (p *Argon2id, salt, key []byte, err error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 {
+		return nil, nil, nil, ErrorUnknownHash
+	}
+	var version int
+	if _, err = fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return
+	}
+	if version != argon2.Version {
+		return nil, nil, nil, fmt.Errorf("unsupported argon2 version: %d", version)
+	}
+	p = new(Argon2id)
+	if _, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Time, &p.Threads); err != nil {
+		return
+	}
+	if salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return
+	}
+	key, err = base64.RawStdEncoding.DecodeString(parts[5])
+	return
+}
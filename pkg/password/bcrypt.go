@@ -0,0 +1,43 @@
+package password
+
+import (
+	"errors"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Bcrypt bcrypt算法，哈希自带 $2a$ 前缀
+type Bcrypt struct {
+	Cost int
+}
+
+func NewBcrypt() *Bcrypt {
+	return &Bcrypt{Cost: bcrypt.DefaultCost}
+}
+
+func (b *Bcrypt) Name() string {
+	return "bcrypt"
+}
+
+func (b *Bcrypt) Hash(password string) (string, error) {
+	h, err := bcrypt.GenerateFromPassword([]byte(password), b.Cost)
+	return string(h), err
+}
+
+func (b *Bcrypt) Match(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") || strings.HasPrefix(encoded, "$2b$") || strings.HasPrefix(encoded, "$2y$")
+}
+
+func (b *Bcrypt) Verify(password, encoded string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (b *Bcrypt) NeedsRehash(encoded string) bool {
+	cost, err := bcrypt.Cost([]byte(encoded))
+	return err != nil || cost != b.Cost
+}
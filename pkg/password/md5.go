@@ -0,0 +1,41 @@
+package password
+
+import (
+	"crypto/md5"
+	"crypto/subtle"
+	"encoding/hex"
+	"strings"
+)
+
+// LegacyMD5 早期版本使用的加盐md5，只用于校验老数据，登录成功后会升级为默认算法
+// 老数据没有算法前缀
+type LegacyMD5 struct {
+	secret string
+}
+
+func NewLegacyMD5(secret string) *LegacyMD5 {
+	return &LegacyMD5{secret: secret}
+}
+
+func (m *LegacyMD5) Name() string {
+	return "md5"
+}
+
+func (m *LegacyMD5) Hash(password string) (string, error) {
+	h := md5.New()
+	h.Write([]byte(m.secret))
+	return hex.EncodeToString(h.Sum([]byte(password))), nil
+}
+
+func (m *LegacyMD5) Match(encoded string) bool {
+	return !strings.HasPrefix(encoded, "$")
+}
+
+func (m *LegacyMD5) Verify(password, encoded string) (bool, error) {
+	h, _ := m.Hash(password)
+	return subtle.ConstantTimeCompare([]byte(h), []byte(encoded)) == 1, nil
+}
+
+func (m *LegacyMD5) NeedsRehash(encoded string) bool {
+	return true
+}
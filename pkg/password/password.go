@@ -0,0 +1,89 @@
+package password
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Hasher 密码哈希算法
+// 生成的哈希字符串都带有算法前缀，据此判断一条记录是由哪种算法生成的
+type Hasher interface {
+	// Name 算法名称
+	Name() string
+	// Hash 计算密码的哈希，返回带算法前缀的字符串
+	Hash(password string) (string, error)
+	// Match 判断哈希字符串是否由该算法生成
+	Match(encoded string) bool
+	// Verify 校验密码与哈希字符串是否一致
+	Verify(password, encoded string) (bool, error)
+	// NeedsRehash 哈希字符串使用的参数是否已经过时
+	NeedsRehash(encoded string) bool
+}
+
+var ErrorUnknownHash = errors.New("unknown password hash")
+
+var (
+	// hashers 已注册的算法，按注册顺序匹配
+	hashers = []Hasher{NewArgon2id(), NewBcrypt()}
+	// defaultHasher 新密码使用的算法
+	defaultHasher = hashers[0]
+)
+
+// Register 注册一种算法，用于校验历史数据
+func Register(h Hasher) {
+	hashers = append(hashers, h)
+}
+
+// Init 设置新密码使用的算法，为空时使用argon2id
+// legacySalt为早期版本md5加密使用的盐，不为空时才能校验md5老密码
+func Init(name, legacySalt string) error {
+	if legacySalt != "" {
+		Register(NewLegacyMD5(legacySalt))
+	}
+	if name == "" {
+		return nil
+	}
+	for _, h := range hashers {
+		if h.Name() == name {
+			defaultHasher = h
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported password hasher: %s", name)
+}
+
+// Hash 使用默认算法计算密码哈希
+func Hash(password string) (string, error) {
+	return defaultHasher.Hash(password)
+}
+
+var (
+	dummyOnce sync.Once
+	dummyHash string
+)
+
+// DummyVerify 用户不存在时校验一个固定的哈希，耗时与校验默认算法生成的密码相同
+// 避免通过响应时间判断用户名是否存在
+func DummyVerify(password string) {
+	dummyOnce.Do(func() {
+		dummyHash, _ = defaultHasher.Hash("bluebell-dummy-password")
+	})
+	_, _, _ = Verify(password, dummyHash)
+}
+
+// Verify 校验密码，needRehash为true表示应该用默认算法重新计算并保存
+func Verify(password, encoded string) (ok, needRehash bool, err error) {
+	for _, h := range hashers {
+		if !h.Match(encoded) {
+			continue
+		}
+		ok, err = h.Verify(password, encoded)
+		if err != nil || !ok {
+			return false, false, err
+		}
+		needRehash = h != defaultHasher || h.NeedsRehash(encoded)
+		return true, needRehash, nil
+	}
+	return false, false, ErrorUnknownHash
+}
@@ -0,0 +1,146 @@
+package password
+
+import (
+	"errors"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const testSalt = "bluebell-test"
+
+// setup 注册md5老密码并设置默认算法，测试结束后恢复全局状态
+func setup(t *testing.T, name string) {
+	oldHashers, oldDefault := hashers, defaultHasher
+	hashers = []Hasher{NewArgon2id(), NewBcrypt()}
+	t.Cleanup(func() { hashers, defaultHasher = oldHashers, oldDefault })
+	if err := Init(name, testSalt); err != nil {
+		t.Fatalf("Init failed, err:%v", err)
+	}
+}
+
+func mustHash(t *testing.T, h Hasher, password string) string {
+	encoded, err := h.Hash(password)
+	if err != nil {
+		t.Fatalf("%s Hash failed, err:%v", h.Name(), err)
+	}
+	return encoded
+}
+
+func TestVerify(t *testing.T) {
+	setup(t, "argon2id")
+	const password = "p@ssw0rd"
+	argon := mustHash(t, NewArgon2id(), password)
+	weakArgon := mustHash(t, &Argon2id{Memory: 8 * 1024, Time: 1, Threads: 1, SaltLen: 16, KeyLen: 32}, password)
+	bc := mustHash(t, &Bcrypt{Cost: bcrypt.MinCost}, password)
+	md5 := mustHash(t, NewLegacyMD5(testSalt), password)
+
+	tests := []struct {
+		name       string
+		password   string
+		encoded    string
+		ok         bool
+		needRehash bool
+		err        error
+	}{
+		{"argon2id", password, argon, true, false, nil},
+		{"argon2id wrong password", "wrong", argon, false, false, nil},
+		{"argon2id outdated params", password, weakArgon, true, true, nil},
+		{"bcrypt", password, bc, true, true, nil},
+		{"bcrypt wrong password", "wrong", bc, false, false, nil},
+		{"legacy md5", password, md5, true, true, nil},
+		{"legacy md5 wrong password", "wrong", md5, false, false, nil},
+		{"unknown hash", password, "$unknown$abc", false, false, ErrorUnknownHash},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, needRehash, err := Verify(tt.password, tt.encoded)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Verify err:%v, want %v", err, tt.err)
+			}
+			if ok != tt.ok || needRehash != tt.needRehash {
+				t.Fatalf("Verify got ok=%v needRehash=%v, want ok=%v needRehash=%v",
+					ok, needRehash, tt.ok, tt.needRehash)
+			}
+		})
+	}
+}
+
+// 默认算法为bcrypt时，cost过时的bcrypt和argon2id密码都需要重新计算
+func TestVerifyNeedRehashBcryptDefault(t *testing.T) {
+	setup(t, "bcrypt")
+	const password = "p@ssw0rd"
+	tests := []struct {
+		name       string
+		encoded    string
+		needRehash bool
+	}{
+		{"default bcrypt", mustHash(t, NewBcrypt(), password), false},
+		{"bcrypt low cost", mustHash(t, &Bcrypt{Cost: bcrypt.MinCost}, password), true},
+		{"argon2id", mustHash(t, NewArgon2id(), password), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, needRehash, err := Verify(password, tt.encoded)
+			if err != nil || !ok {
+				t.Fatalf("Verify got ok=%v err:%v", ok, err)
+			}
+			if needRehash != tt.needRehash {
+				t.Fatalf("Verify needRehash=%v, want %v", needRehash, tt.needRehash)
+			}
+		})
+	}
+}
+
+func TestHashUsesDefault(t *testing.T) {
+	tests := []struct {
+		name   string
+		hasher Hasher
+	}{
+		{"argon2id", NewArgon2id()},
+		{"bcrypt", NewBcrypt()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup(t, tt.name)
+			encoded, err := Hash("p@ssw0rd")
+			if err != nil {
+				t.Fatalf("Hash failed, err:%v", err)
+			}
+			if !tt.hasher.Match(encoded) {
+				t.Fatalf("Hash got %q, want %s hash", encoded, tt.name)
+			}
+		})
+	}
+}
+
+func TestInitUnknownHasher(t *testing.T) {
+	oldHashers, oldDefault := hashers, defaultHasher
+	t.Cleanup(func() { hashers, defaultHasher = oldHashers, oldDefault })
+	if err := Init("sha1", ""); err == nil {
+		t.Fatal("Init with unknown hasher should fail")
+	}
+}
+
+// 没有配置盐时不能校验md5老密码
+func TestVerifyLegacyWithoutSalt(t *testing.T) {
+	oldHashers, oldDefault := hashers, defaultHasher
+	hashers = []Hasher{NewArgon2id(), NewBcrypt()}
+	t.Cleanup(func() { hashers, defaultHasher = oldHashers, oldDefault })
+	md5 := mustHash(t, NewLegacyMD5(testSalt), "p@ssw0rd")
+	if _, _, err := Verify("p@ssw0rd", md5); !errors.Is(err, ErrorUnknownHash) {
+		t.Fatalf("Verify err:%v, want %v", err, ErrorUnknownHash)
+	}
+}
+
+// 用户不存在时同样要完整地校验一次默认算法的哈希
+func TestDummyVerify(t *testing.T) {
+	setup(t, "argon2id")
+	DummyVerify("p@ssw0rd")
+	if !NewArgon2id().Match(dummyHash) {
+		t.Fatalf("dummy hash %q is not an argon2id hash", dummyHash)
+	}
+	if ok, _, err := Verify("p@ssw0rd", dummyHash); err != nil || ok {
+		t.Fatalf("Verify dummy hash got ok=%v err:%v", ok, err)
+	}
+}
@@ -0,0 +1,116 @@
+package tracing
+
+import (
+	"bluebell/setting"
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// 支持的exporter
+const (
+	ExporterNone   = "none"   // 不导出，仍然生成trace id用于日志
+	ExporterStdout = "stdout" // 输出到终端，本地调试使用
+	ExporterFile   = "file"   // 输出到文件，每行一个span
+	ExporterOTLP   = "otlp"   // 通过OTLP/HTTP发送给collector
+)
+
+// tracer 在Init之前获取也可以，Init设置全局provider后自动生效
+var tracer = otel.Tracer("bluebell")
+
+// Init 初始化trace，返回的shutdown在程序退出前调用，把缓冲中的span导出
+// 没有配置trace时按none处理，全部采样但不导出
+func Init(cfg *setting.TraceConfig, name, version string) (shutdown func(context.Context) error, err error) {
+	if cfg == nil {
+		cfg = &setting.TraceConfig{Exporter: ExporterNone, SampleRatio: 1}
+	}
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{}))
+
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL,
+			semconv.ServiceName(name),
+			semconv.ServiceVersion(version),
+		)),
+	}
+	var closer io.Closer
+	switch cfg.Exporter {
+	case "", ExporterNone:
+	case ExporterStdout:
+		exp, err := stdouttrace.New(stdouttrace.WithPrettyPrint())
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, sdktrace.WithBatcher(exp))
+	case ExporterFile:
+		f, err := os.OpenFile(cfg.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, err
+		}
+		exp, err := stdouttrace.New(stdouttrace.WithWriter(f))
+		if err != nil {
+			_ = f.Close()
+			return nil, err
+		}
+		closer = f
+		opts = append(opts, sdktrace.WithBatcher(exp))
+	case ExporterOTLP:
+		clientOpts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.Endpoint)}
+		if cfg.Insecure {
+			clientOpts = append(clientOpts, otlptracehttp.WithInsecure())
+		}
+		exp, err := otlptracehttp.New(context.Background(), clientOpts...)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, sdktrace.WithBatcher(exp))
+	default:
+		return nil, fmt.Errorf("unsupported trace exporter: %s", cfg.Exporter)
+	}
+
+	tp := sdktrace.NewTracerProvider(opts...)
+	otel.SetTracerProvider(tp)
+	return func(ctx context.Context) error {
+		err := tp.Shutdown(ctx)
+		if closer != nil {
+			_ = closer.Close()
+		}
+		return err
+	}, nil
+}
+
+// Start 开始一个子span，调用方需要调用End结束
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End 结束span，err不为nil时记录错误
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// TraceID 获取ctx中的trace id，没有trace时返回空字符串
+func TraceID(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.HasTraceID() {
+		return ""
+	}
+	return sc.TraceID().String()
+}
@@ -0,0 +1,72 @@
+package worker
+
+import (
+	"context"
+	"sync"
+)
+
+// Group 管理程序中的后台任务
+// 每个任务使用单独的ctx，停止时按启动的相反顺序逐个取消并等待其退出
+// 这样后启动的任务(可能依赖先启动的任务)总是先停止
+type Group struct {
+	mu      sync.Mutex
+	workers []*worker
+}
+
+type worker struct {
+	name   string
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Go 启动一个后台任务，fn需要在ctx被取消后尽快返回
+func (g *Group) Go(name string, fn func(ctx context.Context)) {
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &worker{name: name, cancel: cancel, done: make(chan struct{})}
+	g.mu.Lock()
+	g.workers = append(g.workers, w)
+	g.mu.Unlock()
+	go func() {
+		defer close(w.done)
+		fn(ctx)
+	}()
+}
+
+// Stop 按启动的相反顺序停止所有任务
+// ctx超时后不再等待剩余的任务，返回ctx的错误
+func (g *Group) Stop(ctx context.Context) error {
+	g.mu.Lock()
+	workers := g.workers
+	g.workers = nil
+	g.mu.Unlock()
+	for i := len(workers) - 1; i >= 0; i-- {
+		w := workers[i]
+		w.cancel()
+		select {
+		case <-w.done:
+		case <-ctx.Done():
+			// 剩余的任务也要通知退出
+			for _, w := range workers[:i] {
+				w.cancel()
+			}
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// Alive 返回每个任务是否仍在运行
+func (g *Group) Alive() map[string]bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	alive := make(map[string]bool, len(g.workers))
+	for _, w := range g.workers {
+		select {
+		case <-w.done:
+			alive[w.name] = false
+		default:
+			alive[w.name] = true
+		}
+	}
+	return alive
+}
@@ -0,0 +1,61 @@
+package worker
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStopInReverseOrder(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		order []string
+	)
+	g := new(Group)
+	for _, name := range []string{"first", "second", "third"} {
+		name := name
+		g.Go(name, func(ctx context.Context) {
+			<-ctx.Done()
+			mu.Lock()
+			order = append(order, name)
+			mu.Unlock()
+		})
+	}
+	if err := g.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"third", "second", "first"}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("stop order = %v, want %v", order, want)
+		}
+	}
+}
+
+func TestStopDeadline(t *testing.T) {
+	g := new(Group)
+	block := make(chan struct{})
+	defer close(block)
+	g.Go("stuck", func(ctx context.Context) { <-block })
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := g.Stop(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("err = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestAlive(t *testing.T) {
+	g := new(Group)
+	g.Go("running", func(ctx context.Context) { <-ctx.Done() })
+	exited := make(chan struct{})
+	g.Go("exited", func(ctx context.Context) { close(exited) })
+	<-exited
+	// 等待任务的goroutine执行完defer
+	time.Sleep(10 * time.Millisecond)
+	alive := g.Alive()
+	if !alive["running"] || alive["exited"] {
+		t.Fatalf("alive = %v", alive)
+	}
+	_ = g.Stop(context.Background())
+}
@@ -4,6 +4,8 @@ import (
 	"bluebell/controller"
 	"bluebell/logger"
 	"bluebell/middlewares"
+	"bluebell/models"
+	"bluebell/pkg/metrics"
 	"net/http"
 
 	"github.com/gin-contrib/pprof"
@@ -11,13 +13,25 @@ import (
 )
 
 // SetupRouter 路由
-func SetupRouter(mode string) *gin.Engine {
+// trustedProxies为空时不信任X-Forwarded-For，c.ClientIP()返回连接的对端地址
+func SetupRouter(mode string, trustedProxies []string) (*gin.Engine, error) {
 	if mode == gin.ReleaseMode {
 		gin.SetMode(gin.ReleaseMode) // gin设置成发布模式
 	}
 	r := gin.New()
-	//r.Use(logger.GinLogger(), logger.GinRecovery(true), middlewares.RateLimitMiddleware(2*time.Second, 1))
-	r.Use(logger.GinLogger(), logger.GinRecovery(true))
+	// 限流和登录锁定按客户端ip计数，不能让客户端通过伪造请求头绕过
+	if len(trustedProxies) == 0 {
+		trustedProxies = nil
+	}
+	if err := r.SetTrustedProxies(trustedProxies); err != nil {
+		return nil, err
+	}
+	// 健康检查和监控指标在中间件之前注册，探针和采集请求不记录日志也不限流
+	r.GET("/healthz", controller.HealthzHandler)
+	r.GET("/readyz", controller.ReadyzHandler)
+	r.GET("/metrics", gin.WrapH(metrics.Handler()))
+
+	r.Use(middlewares.TraceMiddleware(), middlewares.RequestIDMiddleware(), middlewares.LocaleMiddleware(), middlewares.MetricsMiddleware(), logger.GinLogger(), logger.GinRecovery(true), middlewares.RateLimitMiddleware())
 
 	r.LoadHTMLFiles("./templates/index.html")
 	r.Static("/static", "./static")
@@ -30,35 +44,76 @@ func SetupRouter(mode string) *gin.Engine {
 		c.String(http.StatusOK, "pong")
 	})
 
-	v1 := r.Group("/api/v1")
+	// 签名token的公钥
+	r.GET("/.well-known/jwks.json", controller.JWKSHandler)
+
+	// v1只通过返回的code区分成功和失败，http状态码始终是200
+	// v2返回错误时同时使用对应的http状态码，两个版本的接口相同
+	registerAPI(r.Group("/api/v1"))
+	registerAPI(r.Group("/api/v2", middlewares.APIVersionMiddleware(2)))
+
+	pprof.Register(r) // 注册pprof相关路由
+	// 缓存的内部状态只允许管理员查看
+	r.GET("/debug/cache", middlewares.JWTAuthMiddleware(), middlewares.PermissionMiddleware(models.PermViewDebug), controller.CacheStatsHandler)
+
+	r.NoRoute(func(c *gin.Context) {
+		controller.ResponseErrorWithStatus(c, http.StatusNotFound, controller.CodeNotFound)
+	})
+	return r, nil
+}
 
+// registerAPI 注册业务接口
+func registerAPI(g *gin.RouterGroup) {
 	// 注册
-	v1.POST("/signup", controller.SignUpHandler)
+	g.POST("/signup", controller.SignUpHandler)
 	// 登录
-	v1.POST("/login", controller.LoginHandler)
+	g.POST("/login", controller.LoginHandler)
+	// 刷新token
+	g.POST("/token/refresh", controller.RefreshTokenHandler)
 
 	// 根据时间或分数获取帖子列表
-	v1.GET("/posts2", controller.GetPostListHandler2)
-	v1.GET("/posts", controller.GetPostListHandler)
-	v1.GET("/community", controller.CommunityHandler)
-	v1.GET("/community/:id", controller.CommunityDetailHandler)
-	v1.GET("/post/:id", controller.GetPostDetailHandler)
+	g.GET("/posts2", controller.GetPostListHandler2)
+	g.GET("/posts", controller.GetPostListHandler)
+	g.GET("/community", controller.CommunityHandler)
+	g.GET("/community/:id", controller.CommunityDetailHandler)
+	g.GET("/post/:id", controller.GetPostDetailHandler)
+	g.GET("/post/:id/comments", controller.GetCommentListHandler)
+	g.GET("/post/:id/revisions", controller.GetPostRevisionsHandler)
 
-	v1.Use(middlewares.JWTAuthMiddleware()) // 应用JWT认证中间件
+	g.Use(middlewares.JWTAuthMiddleware()) // 应用JWT认证中间件
 
 	{
-		v1.POST("/post", controller.CreatePostHandler)
+		// 退出登录
+		g.POST("/logout", controller.LogoutHandler)
+
+		g.POST("/post", controller.CreatePostHandler)
+		g.PUT("/post/:id", controller.UpdatePostHandler)
+		g.DELETE("/post/:id", controller.DeletePostHandler)
+
+		// 社区管理
+		g.POST("/community", controller.CreateCommunityHandler)
+		g.PUT("/community/:id", controller.UpdateCommunityHandler)
+		g.POST("/community/:id/archive", controller.ArchiveCommunityHandler)
+
+		// 订阅社区及首页推荐
+		g.POST("/community/:id/subscribe", controller.SubscribeHandler)
+		g.DELETE("/community/:id/subscribe", controller.UnsubscribeHandler)
+		g.GET("/feed", controller.GetFeedHandler)
 
 		// 投票
-		v1.POST("/vote", controller.PostVoteController)
-	}
+		g.POST("/vote", controller.PostVoteController)
 
-	pprof.Register(r) // 注册pprof相关路由
+		// 评论
+		g.POST("/post/:id/comments", controller.CreateCommentHandler)
+		g.POST("/comment/vote", controller.CommentVoteController)
 
-	r.NoRoute(func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{
-			"msg": "404",
-		})
-	})
-	return r
+		// 版主及管理员的管理操作
+		g.POST("/mod/post/:id/remove", middlewares.PermissionMiddleware(models.PermRemovePost), controller.RemovePostHandler)
+		g.POST("/mod/post/:id/lock", middlewares.PermissionMiddleware(models.PermLockPost), controller.LockPostHandler)
+		g.POST("/mod/post/:id/unlock", middlewares.PermissionMiddleware(models.PermLockPost), controller.UnlockPostHandler)
+		g.POST("/admin/user/:id/ban", middlewares.PermissionMiddleware(models.PermBanUser), controller.BanUserHandler)
+		g.POST("/admin/user/:id/unban", middlewares.PermissionMiddleware(models.PermBanUser), controller.UnbanUserHandler)
+		g.POST("/admin/community/:id/moderators", middlewares.PermissionMiddleware(models.PermManageModerator), controller.AddModeratorHandler)
+		g.DELETE("/admin/community/:id/moderators/:uid", middlewares.PermissionMiddleware(models.PermManageModerator), controller.RemoveModeratorHandler)
+	}
 }
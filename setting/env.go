@@ -0,0 +1,40 @@
+package setting
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// EnvPrefix 环境变量前缀，例如 BLUEBELL_MYSQL_PASSWORD 覆盖 mysql.password
+const EnvPrefix = "BLUEBELL"
+
+// bindEnvs 为配置结构体中的每个key绑定环境变量
+// viper的AutomaticEnv只对配置文件中出现过的key生效，绑定之后配置文件中没有的key也能通过环境变量设置
+func bindEnvs(t reflect.Type, prefix string) {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("mapstructure")
+		if tag == "" || tag == "-" {
+			continue
+		}
+		key := prefix + tag
+		ft := field.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct {
+			bindEnvs(ft, key+".")
+			continue
+		}
+		// 列表类型的配置无法用一个环境变量表示
+		if ft.Kind() == reflect.Slice && ft.Elem().Kind() == reflect.Struct {
+			continue
+		}
+		_ = viper.BindEnv(key, EnvPrefix+"_"+strings.ToUpper(strings.ReplaceAll(key, ".", "_")))
+	}
+}
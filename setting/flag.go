@@ -0,0 +1,34 @@
+package setting
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Overrides 命令行中通过 -set key=value 指定的配置，可以多次使用
+// 实现了flag.Value接口
+type Overrides map[string]string
+
+func (o Overrides) String() string {
+	pairs := make([]string, 0, len(o))
+	for k, v := range o {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (o Overrides) Set(s string) error {
+	key, value, ok := strings.Cut(s, "=")
+	key = strings.TrimSpace(key)
+	if !ok || key == "" {
+		return errors.New("override must be in key=value form")
+	}
+	if _, exist := o[key]; exist {
+		return fmt.Errorf("%s is overridden more than once", key)
+	}
+	o[key] = value
+	return nil
+}
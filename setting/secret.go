@@ -0,0 +1,37 @@
+package setting
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ResolveSecret 解析密钥类配置，支持三种写法:
+//
+//	env:NAME   从环境变量NAME读取
+//	file:PATH  从文件读取，忽略末尾的空白字符
+//	其他       直接作为密钥，仅建议在开发环境使用
+func ResolveSecret(spec string) (string, error) {
+	var secret string
+	switch {
+	case strings.HasPrefix(spec, "env:"):
+		name := strings.TrimPrefix(spec, "env:")
+		secret = os.Getenv(name)
+		if secret == "" {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+	case strings.HasPrefix(spec, "file:"):
+		path := strings.TrimPrefix(spec, "file:")
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		secret = strings.TrimRight(string(b), " \t\r\n")
+		if secret == "" {
+			return "", fmt.Errorf("key file %s is empty", path)
+		}
+	default:
+		secret = spec
+	}
+	return secret, nil
+}
@@ -2,12 +2,22 @@ package setting
 
 import (
 	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/fsnotify/fsnotify"
+	"github.com/mitchellh/mapstructure"
 	"github.com/spf13/viper"
 )
 
-var Conf = new(AppConfig)
+var (
+	current atomic.Pointer[AppConfig]
+
+	mu        sync.Mutex // 保证回调函数按配置修改的顺序执行
+	callbacks []func(oldCfg, newCfg *AppConfig)
+)
 
 type AppConfig struct {
 	Name      string `mapstructure:"name"`
@@ -17,9 +27,49 @@ type AppConfig struct {
 	MachineID int64  `mapstructure:"machine_id"`
 	Port      int    `mapstructure:"port"`
 
-	*LogConfig   `mapstructure:"log"`
-	*MySQLConfig `mapstructure:"mysql"`
-	*RedisConfig `mapstructure:"redis"`
+	ReadTimeout     int `mapstructure:"read_timeout"`     // 读取请求的超时时间(秒)
+	WriteTimeout    int `mapstructure:"write_timeout"`    // 写响应的超时时间(秒)
+	IdleTimeout     int `mapstructure:"idle_timeout"`     // keep-alive连接的空闲超时时间(秒)
+	ShutdownDelay   int `mapstructure:"shutdown_delay"`   // 收到退出信号后/readyz返回503，等待多久再停止接受新请求(秒)
+	ShutdownTimeout int `mapstructure:"shutdown_timeout"` // 退出时等待处理中的请求及后台任务结束的最长时间(秒)
+
+	// TrustedProxies 前面的反向代理的ip或网段，只信任它们传来的X-Forwarded-For，为空表示直接对外提供服务
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
+
+	*AuthConfig      `mapstructure:"auth"`
+	*LogConfig       `mapstructure:"log"`
+	*MySQLConfig     `mapstructure:"mysql"`
+	*RedisConfig     `mapstructure:"redis"`
+	*ArchiveConfig   `mapstructure:"archive"`
+	*CacheConfig     `mapstructure:"cache"`
+	*RateLimitConfig `mapstructure:"rate_limit"`
+	*LoginConfig     `mapstructure:"login"`
+	*TraceConfig     `mapstructure:"trace"`
+	*I18nConfig      `mapstructure:"i18n"`
+}
+
+type AuthConfig struct {
+	AccessTokenExpire  int    `mapstructure:"access_token_expire"`
+	RefreshTokenExpire int    `mapstructure:"refresh_token_expire"`
+	PasswordHasher     string `mapstructure:"password_hasher"`
+	// LegacyMD5Salt 早期版本md5加密使用的盐，仅用于校验尚未升级的老密码，为空时不再接受md5密码，写法见ResolveSecret
+	LegacyMD5Salt string `mapstructure:"legacy_md5_salt"`
+	// JWTActiveKey 签发新token使用的key的kid
+	JWTActiveKey string `mapstructure:"jwt_active_kid"`
+	// JWTLegacyKey 可选，header中没有kid的token(支持多个key之前签发的)使用这个key校验
+	JWTLegacyKey string `mapstructure:"jwt_legacy_kid"`
+	// JWTKeys 校验token时接受的所有key
+	// 轮换时先加入新key并切换JWTActiveKey，旧key设置RetireAt，到期后再从配置中删除
+	JWTKeys []JWTKey `mapstructure:"jwt_keys"`
+}
+
+type JWTKey struct {
+	ID         string `mapstructure:"kid"`
+	Alg        string `mapstructure:"alg"`         // HS256/RS256/EdDSA，为空时使用HS256
+	Secret     string `mapstructure:"secret"`      // HS256的密钥，写法见ResolveSecret
+	PrivateKey string `mapstructure:"private_key"` // RS256/EdDSA的PEM格式私钥，一般写作 file:PATH
+	// RetireAt 已停用的key只用于校验，到这个时间(RFC3339)后不再接受，应晚于它签发的最后一个token的过期时间
+	RetireAt time.Time `mapstructure:"retire_at"`
 }
 
 type MySQLConfig struct {
@@ -41,6 +91,54 @@ type RedisConfig struct {
 	MinIdleConns int    `mapstructure:"min_idle_conns"`
 }
 
+type ArchiveConfig struct {
+	Interval  int `mapstructure:"interval"`   // 归档投票数据的间隔(分钟)
+	BatchSize int `mapstructure:"batch_size"` // 每次最多归档的帖子数
+}
+
+type CacheConfig struct {
+	LocalSize int `mapstructure:"local_size"` // 本地缓存最多保存的条目数，0表示不使用本地缓存
+	LocalTTL  int `mapstructure:"local_ttl"`  // 本地缓存有效期(秒)
+	RedisTTL  int `mapstructure:"redis_ttl"`  // redis缓存有效期(秒)
+}
+
+type RateLimitConfig struct {
+	Default RateLimitRule   `mapstructure:"default"` // 所有接口共用的限制
+	Routes  []RateLimitRule `mapstructure:"routes"`  // 单独限制的接口，不再计入默认限制
+}
+
+type RateLimitRule struct {
+	Method string `mapstructure:"method"` // 只用于routes
+	Path   string `mapstructure:"path"`   // gin注册的路由，如 /api/v1/post/:id，只用于routes
+	Rate   int64  `mapstructure:"rate"`   // period内允许的请求数，0表示不限流
+	Burst  int64  `mapstructure:"burst"`  // 最多允许的突发请求数，为0时等于rate
+	Period int    `mapstructure:"period"` // 时间窗口(秒)
+}
+
+// LoginConfig 登录防暴力破解
+// 连续失败超过免费次数后，每次失败锁定base_delay * 2^(超出次数-1)秒，最长max_delay秒
+type LoginConfig struct {
+	UserFreeAttempts int `mapstructure:"user_free_attempts"` // 同一用户名允许连续失败的次数
+	IPFreeAttempts   int `mapstructure:"ip_free_attempts"`   // 同一ip允许连续失败的次数
+	BaseDelay        int `mapstructure:"base_delay"`         // 第一次锁定的时间(秒)
+	MaxDelay         int `mapstructure:"max_delay"`          // 最长锁定时间(秒)
+	Window           int `mapstructure:"window"`             // 最后一次失败多久之后清零失败次数(秒)
+}
+
+type TraceConfig struct {
+	Exporter    string  `mapstructure:"exporter"`     // none/stdout/file/otlp
+	Endpoint    string  `mapstructure:"endpoint"`     // otlp collector的地址，如 localhost:4318
+	Insecure    bool    `mapstructure:"insecure"`     // otlp不使用https
+	File        string  `mapstructure:"file"`         // exporter为file时写入的文件
+	SampleRatio float64 `mapstructure:"sample_ratio"` // 采样比例 0~1
+}
+
+// I18nConfig 提示信息的多语言支持，dir下每种语言一个消息文件，如 zh.yaml
+type I18nConfig struct {
+	Dir         string `mapstructure:"dir"`          // 消息文件所在的目录
+	DefaultLang string `mapstructure:"default_lang"` // 请求的语言没有对应的消息文件时使用的语言
+}
+
 type LogConfig struct {
 	Level      string `mapstructure:"level"`
 	Filename   string `mapstructure:"filename"`
@@ -49,27 +147,83 @@ type LogConfig struct {
 	MaxBackups int    `mapstructure:"max_backups"`
 }
 
-func Init(filePath string) (err error) {
+// Get 获取当前的配置，配置文件修改后会返回新的配置
+// 返回的配置不能修改，需要的话先复制一份
+func Get() *AppConfig {
+	return current.Load()
+}
+
+// OnChange 注册配置修改后的回调函数，按注册顺序依次调用
+func OnChange(fn func(oldCfg, newCfg *AppConfig)) {
+	mu.Lock()
+	defer mu.Unlock()
+	callbacks = append(callbacks, fn)
+}
+
+// Init 读取配置文件，优先级从高到低依次为: overrides(命令行参数) > BLUEBELL_*环境变量 > 配置文件 > 默认值
+// 配置不合法时返回所有的问题
+func Init(filePath string, overrides map[string]string) (err error) {
 	viper.SetConfigFile(filePath)
+	viper.SetDefault("read_timeout", 10)
+	viper.SetDefault("write_timeout", 60)
+	viper.SetDefault("idle_timeout", 120)
+	viper.SetDefault("shutdown_timeout", 15)
+	bindEnvs(reflect.TypeOf(AppConfig{}), "")
+	for key, value := range overrides {
+		viper.Set(key, value)
+	}
 
 	err = viper.ReadInConfig() // 读取配置信息
 	if err != nil {
 		// 读取配置信息失败
-		fmt.Printf("viper.ReadInConfig failed, err:%v\n", err)
-		return
+		return fmt.Errorf("viper.ReadInConfig failed, err:%w", err)
 	}
 
-	// 把读取到的配置信息反序列化到 Conf 变量中
-	if err := viper.Unmarshal(Conf); err != nil {
-		fmt.Printf("viper.Unmarshal failed, err:%v\n", err)
+	// 把读取到的配置信息反序列化到配置结构体中
+	cfg, err := load()
+	if err != nil {
+		return err
 	}
+	current.Store(cfg)
 
 	viper.WatchConfig()
 	viper.OnConfigChange(func(in fsnotify.Event) {
 		fmt.Println("配置文件修改了...")
-		if err := viper.Unmarshal(Conf); err != nil {
-			fmt.Printf("viper.Unmarshal failed, err:%v\n", err)
-		}
+		reload()
 	})
 	return
 }
+
+// load 反序列化并检查配置
+func load() (*AppConfig, error) {
+	cfg := new(AppConfig)
+	// 时间类型的配置项可以写成RFC3339格式的字符串，环境变量和-set覆盖的值都是字符串
+	hook := viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
+		mapstructure.StringToTimeDurationHookFunc(),
+		mapstructure.StringToSliceHookFunc(","),
+		mapstructure.StringToTimeHookFunc(time.RFC3339),
+	))
+	if err := viper.Unmarshal(cfg, hook); err != nil {
+		return nil, fmt.Errorf("viper.Unmarshal failed, err:%w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config:\n%w", err)
+	}
+	return cfg, nil
+}
+
+// reload 重新读取配置，整体替换后通知各个模块
+// 解析失败或配置不合法时继续使用原来的配置
+func reload() {
+	cfg, err := load()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	old := current.Swap(cfg)
+	for _, fn := range callbacks {
+		fn(old, cfg)
+	}
+}
@@ -0,0 +1,153 @@
+package setting
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// snowflake节点id占10位
+const maxMachineID = 1<<10 - 1
+
+var logLevels = map[string]bool{
+	"debug": true, "info": true, "warn": true, "error": true,
+	"dpanic": true, "panic": true, "fatal": true,
+}
+
+// Validate 检查配置是否合法，一次返回所有的问题
+func (c *AppConfig) Validate() error {
+	v := new(validator)
+	v.check(c.Name != "", "name is required")
+	v.check(c.Mode == "dev" || c.Mode == "release", "mode must be dev or release, got %q", c.Mode)
+	v.check(c.Port > 0 && c.Port <= 65535, "port must be between 1 and 65535, got %d", c.Port)
+	v.check(c.MachineID >= 0 && c.MachineID <= maxMachineID,
+		"machine_id must be between 0 and %d, got %d", maxMachineID, c.MachineID)
+	if st, err := time.Parse("2006-01-02", c.StartTime); err != nil {
+		v.add("start_time must be a date like 2006-01-02, got %q", c.StartTime)
+	} else {
+		v.check(st.Before(time.Now()), "start_time must be in the past, got %q", c.StartTime)
+	}
+	v.check(c.ReadTimeout >= 0, "read_timeout must not be negative")
+	v.check(c.WriteTimeout >= 0, "write_timeout must not be negative")
+	v.check(c.IdleTimeout >= 0, "idle_timeout must not be negative")
+	v.check(c.ShutdownDelay >= 0, "shutdown_delay must not be negative")
+	v.check(c.ShutdownTimeout > 0, "shutdown_timeout must be positive")
+	for idx, proxy := range c.TrustedProxies {
+		_, _, cidrErr := net.ParseCIDR(proxy)
+		v.check(cidrErr == nil || net.ParseIP(proxy) != nil,
+			"trusted_proxies[%d] %q is not an ip or cidr", idx, proxy)
+	}
+
+	if v.section(c.AuthConfig != nil, "auth") {
+		v.check(c.AccessTokenExpire > 0, "auth.access_token_expire must be positive")
+		v.check(c.RefreshTokenExpire > 0, "auth.refresh_token_expire must be positive")
+		v.check(time.Duration(c.RefreshTokenExpire)*time.Hour > time.Duration(c.AccessTokenExpire)*time.Minute,
+			"auth.refresh_token_expire must be longer than auth.access_token_expire")
+		v.check(c.PasswordHasher != "", "auth.password_hasher is required")
+		v.check(len(c.JWTKeys) > 0, "auth.jwt_keys is required")
+		kids := make(map[string]bool, len(c.JWTKeys))
+		for idx, k := range c.JWTKeys {
+			v.check(k.ID != "", "auth.jwt_keys[%d].kid is required", idx)
+			switch k.Alg {
+			case "", "HS256":
+				v.check(k.Secret != "", "auth.jwt_keys[%d].secret is required", idx)
+			case "RS256", "EdDSA":
+				v.check(k.PrivateKey != "", "auth.jwt_keys[%d].private_key is required", idx)
+			default:
+				v.add("auth.jwt_keys[%d].alg %q is unsupported", idx, k.Alg)
+			}
+			v.check(!kids[k.ID], "auth.jwt_keys[%d].kid %q is duplicated", idx, k.ID)
+			kids[k.ID] = true
+			v.check(k.RetireAt.IsZero() || k.ID != c.JWTActiveKey,
+				"auth.jwt_keys[%d] is retired and can not be the active key", idx)
+		}
+		v.check(kids[c.JWTActiveKey], "auth.jwt_active_kid %q is not in auth.jwt_keys", c.JWTActiveKey)
+		v.check(c.JWTLegacyKey == "" || kids[c.JWTLegacyKey], "auth.jwt_legacy_kid %q is not in auth.jwt_keys", c.JWTLegacyKey)
+	}
+	if v.section(c.LogConfig != nil, "log") {
+		v.check(logLevels[c.LogConfig.Level], "log.level %q is unknown", c.LogConfig.Level)
+		v.check(c.Filename != "", "log.filename is required")
+	}
+	if v.section(c.MySQLConfig != nil, "mysql") {
+		v.check(c.MySQLConfig.Host != "", "mysql.host is required")
+		v.check(c.MySQLConfig.Port > 0 && c.MySQLConfig.Port <= 65535, "mysql.port must be between 1 and 65535")
+		v.check(c.MySQLConfig.DB != "", "mysql.dbname is required")
+		v.check(c.MaxOpenConns >= 0, "mysql.max_open_conns must not be negative")
+		v.check(c.MaxIdleConns >= 0, "mysql.max_idle_conns must not be negative")
+	}
+	if v.section(c.RedisConfig != nil, "redis") {
+		v.check(c.RedisConfig.Host != "", "redis.host is required")
+		v.check(c.RedisConfig.Port > 0 && c.RedisConfig.Port <= 65535, "redis.port must be between 1 and 65535")
+		v.check(c.RedisConfig.DB >= 0, "redis.db must not be negative")
+		v.check(c.PoolSize >= 0, "redis.pool_size must not be negative")
+		v.check(c.MinIdleConns >= 0, "redis.min_idle_conns must not be negative")
+	}
+	if v.section(c.ArchiveConfig != nil, "archive") {
+		v.check(c.Interval > 0, "archive.interval must be positive")
+		v.check(c.BatchSize > 0, "archive.batch_size must be positive")
+	}
+	if v.section(c.CacheConfig != nil, "cache") {
+		v.check(c.LocalSize >= 0, "cache.local_size must not be negative")
+		v.check(c.LocalTTL >= 0, "cache.local_ttl must not be negative")
+		v.check(c.RedisTTL > 0, "cache.redis_ttl must be positive")
+	}
+	if v.section(c.LoginConfig != nil, "login") {
+		v.check(c.UserFreeAttempts > 0, "login.user_free_attempts must be positive")
+		v.check(c.IPFreeAttempts > 0, "login.ip_free_attempts must be positive")
+		v.check(c.BaseDelay > 0, "login.base_delay must be positive")
+		v.check(c.MaxDelay >= c.BaseDelay, "login.max_delay must not be less than login.base_delay")
+		v.check(c.Window > 0, "login.window must be positive")
+	}
+	if v.section(c.I18nConfig != nil, "i18n") {
+		v.check(c.I18nConfig.Dir != "", "i18n.dir is required")
+		v.check(c.DefaultLang != "", "i18n.default_lang is required")
+	}
+	if c.TraceConfig != nil {
+		switch c.TraceConfig.Exporter {
+		case "", "none", "stdout":
+		case "file":
+			v.check(c.TraceConfig.File != "", "trace.file is required when trace.exporter is file")
+		case "otlp":
+			v.check(c.Endpoint != "", "trace.endpoint is required when trace.exporter is otlp")
+		default:
+			v.add("trace.exporter %q is unsupported", c.TraceConfig.Exporter)
+		}
+		v.check(c.SampleRatio >= 0 && c.SampleRatio <= 1, "trace.sample_ratio must be between 0 and 1")
+	}
+	if c.RateLimitConfig != nil {
+		v.checkRateLimitRule("rate_limit.default", c.RateLimitConfig.Default)
+		for idx, r := range c.RateLimitConfig.Routes {
+			name := fmt.Sprintf("rate_limit.routes[%d]", idx)
+			v.check(r.Method != "" && r.Path != "", "%s.method and %s.path are required", name, name)
+			v.checkRateLimitRule(name, r)
+		}
+	}
+	return errors.Join(v.errs...)
+}
+
+type validator struct {
+	errs []error
+}
+
+func (v *validator) add(format string, args ...interface{}) {
+	v.errs = append(v.errs, fmt.Errorf(format, args...))
+}
+
+func (v *validator) check(ok bool, format string, args ...interface{}) {
+	if !ok {
+		v.add(format, args...)
+	}
+}
+
+func (v *validator) checkRateLimitRule(name string, r RateLimitRule) {
+	v.check(r.Rate >= 0, "%s.rate must not be negative", name)
+	v.check(r.Burst >= 0, "%s.burst must not be negative", name)
+	v.check(r.Rate == 0 || r.Period > 0, "%s.period must be positive", name)
+}
+
+// section 检查配置段是否存在，不存在时不再检查其中的配置项
+func (v *validator) section(ok bool, name string) bool {
+	v.check(ok, "%s section is missing", name)
+	return ok
+}
@@ -0,0 +1,26 @@
+package setting
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateReportsAllProblems(t *testing.T) {
+	cfg := &AppConfig{
+		Name:            "bluebell",
+		Mode:            "release",
+		Port:            0,
+		MachineID:       1024,
+		StartTime:       "2024/11/10",
+		ShutdownTimeout: 15,
+	}
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	for _, want := range []string{"port", "machine_id", "start_time", "auth section is missing", "mysql section is missing"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+create table comment
+(
+    id          bigint auto_increment
+        primary key,
+    comment_id  bigint                              not null comment '评论id',
+    post_id     bigint                              not null comment '所属帖子id',
+    parent_id   bigint    default 0                 not null comment '回复的评论id，0表示直接回复帖子',
+    author_id   bigint                              not null comment '作者的用户id',
+    content     varchar(2048)                       not null comment '内容',
+    status      tinyint   default 1                 not null comment '评论状态',
+    create_time timestamp default CURRENT_TIMESTAMP null comment '创建时间',
+    update_time timestamp default CURRENT_TIMESTAMP null on update CURRENT_TIMESTAMP comment '更新时间',
+    constraint idx_comment_id
+        unique (comment_id)
+)
+    collate = utf8mb4_general_ci;
+
+create index idx_post_parent
+    on comment (post_id, parent_id, create_time);
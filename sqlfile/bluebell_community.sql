@@ -2,9 +2,11 @@ create table community
 (
     id             int auto_increment
         primary key,
-    community_id   int unsigned                        not null,
+    community_id   bigint                              not null,
     community_name varchar(128)                        not null,
     introduction   varchar(256)                        not null,
+    owner_id       bigint    default 0                 not null comment '创建人的用户id',
+    status         tinyint   default 1                 not null comment '状态 1:正常 2:已归档',
     create_time    timestamp default CURRENT_TIMESTAMP not null,
     update_time    timestamp default CURRENT_TIMESTAMP not null on update CURRENT_TIMESTAMP,
     constraint idx_community_id
@@ -0,0 +1,14 @@
+create table community_moderator
+(
+    id           bigint auto_increment
+        primary key,
+    community_id bigint                              not null comment '社区id',
+    user_id      bigint                              not null comment '版主的用户id',
+    create_time  timestamp default CURRENT_TIMESTAMP null comment '指派时间',
+    constraint idx_community_user
+        unique (community_id, user_id)
+)
+    collate = utf8mb4_general_ci;
+
+create index idx_user_id
+    on community_moderator (user_id);
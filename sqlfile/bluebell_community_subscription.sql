@@ -0,0 +1,11 @@
+create table community_subscription
+(
+    id           bigint auto_increment
+        primary key,
+    user_id      bigint                              not null comment '订阅的用户id',
+    community_id bigint                              not null comment '社区id',
+    create_time  timestamp default CURRENT_TIMESTAMP null comment '订阅时间',
+    constraint idx_user_community
+        unique (user_id, community_id)
+)
+    collate = utf8mb4_general_ci;
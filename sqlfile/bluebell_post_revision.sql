@@ -0,0 +1,14 @@
+create table post_revision
+(
+    id          bigint auto_increment
+        primary key,
+    post_id     bigint                              not null comment '帖子id',
+    editor_id   bigint                              not null comment '修改人的用户id',
+    title       varchar(128)                        not null comment '修改前的标题',
+    content     varchar(8192)                       not null comment '修改前的内容',
+    create_time timestamp default CURRENT_TIMESTAMP null comment '修改时间'
+)
+    collate = utf8mb4_general_ci;
+
+create index idx_post_id
+    on post_revision (post_id);
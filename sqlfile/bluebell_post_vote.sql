@@ -0,0 +1,25 @@
+create table post_vote
+(
+    id          bigint auto_increment
+        primary key,
+    post_id     bigint                              not null comment '帖子id',
+    up_count    int       default 0                 not null comment '赞成票数',
+    down_count  int       default 0                 not null comment '反对票数',
+    create_time timestamp default CURRENT_TIMESTAMP null comment '归档时间',
+    constraint idx_post_id
+        unique (post_id)
+)
+    collate = utf8mb4_general_ci;
+
+create table post_vote_record
+(
+    id          bigint auto_increment
+        primary key,
+    post_id     bigint                              not null comment '帖子id',
+    user_id     bigint                              not null comment '投票的用户id',
+    direction   tinyint                             not null comment '赞成票(1)还是反对票(-1)',
+    create_time timestamp default CURRENT_TIMESTAMP null comment '归档时间',
+    constraint idx_post_user
+        unique (post_id, user_id)
+)
+    collate = utf8mb4_general_ci;
@@ -4,9 +4,11 @@ create table user
         primary key,
     user_id     bigint                              not null,
     username    varchar(64)                         not null,
-    password    varchar(64)                         not null,
+    password    varchar(255)                        not null,
     email       varchar(64)                         null,
     gender      tinyint   default 0                 not null,
+    role        tinyint   default 0                 not null comment '角色 0:普通用户 1:版主 2:管理员',
+    status      tinyint   default 0                 not null comment '状态 0:正常 1:封禁',
     create_time timestamp default CURRENT_TIMESTAMP null,
     update_time timestamp default CURRENT_TIMESTAMP null on update CURRENT_TIMESTAMP,
     constraint idx_user_id
@@ -0,0 +1,17 @@
+-- 已有数据库升级到新版本时需要执行的语句，按时间顺序追加
+
+-- 密码改为argon2id/bcrypt保存，哈希长度超过64
+alter table user modify password varchar(255) not null;
+
+-- 用户角色及封禁状态
+alter table user add role tinyint default 0 not null comment '角色 0:普通用户 1:版主 2:管理员' after gender;
+alter table user add status tinyint default 0 not null comment '状态 0:正常 1:封禁' after role;
+
+-- 社区支持通过接口创建，community_id改为雪花算法生成
+alter table community modify community_id bigint not null;
+alter table community add owner_id bigint default 0 not null comment '创建人的用户id' after introduction;
+alter table community add status tinyint default 1 not null comment '状态 1:正常 2:已归档' after owner_id;
+
+-- 评论列表按父评论逐层查询
+create index idx_post_parent on comment (post_id, parent_id, create_time);
+drop index idx_post_id on comment;